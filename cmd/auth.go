@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"torus-neighbors/internal/api"
+)
+
+// headerList collects repeated -header flags.
+type headerList []string
+
+func (h *headerList) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerList) Set(value string) error {
+	if _, _, err := api.ParseHeader(value); err != nil {
+		return err
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+type authFlags struct {
+	headers       headerList
+	secretHeaders headerList
+	userAgent     string
+	tokenEnv      string
+	tokenFile     string
+	tokenHeader   string
+	basicAuth     string
+}
+
+// clientOptions turns the header and credential flags into client options.
+func (f *authFlags) clientOptions() ([]api.ClientOption, error) {
+	var options []api.ClientOption
+	for _, header := range f.headers {
+		key, value, _ := api.ParseHeader(header)
+		options = append(options, api.WithHeader(key, value))
+	}
+	for _, header := range f.secretHeaders {
+		key, value, _ := api.ParseHeader(header)
+		options = append(options, api.WithSecretHeader(key, value))
+	}
+	if f.userAgent != "" {
+		options = append(options, api.WithUserAgent(f.userAgent))
+	}
+
+	var provider api.TokenProvider
+	switch {
+	case f.tokenEnv != "" && f.tokenFile != "":
+		return nil, errors.New("-token-env and -token-file are mutually exclusive")
+	case f.tokenEnv != "":
+		provider = api.EnvToken(f.tokenEnv)
+	case f.tokenFile != "":
+		provider = api.FileToken(f.tokenFile)
+	}
+
+	switch {
+	case provider != nil && f.basicAuth != "":
+		return nil, errors.New("-basic-auth cannot be combined with a token")
+	case provider != nil && f.tokenHeader != "":
+		options = append(options, api.WithAPIKey(f.tokenHeader, provider))
+	case provider != nil:
+		options = append(options, api.WithBearerToken(provider))
+	case f.basicAuth != "":
+		username, password, ok := strings.Cut(f.basicAuth, ":")
+		if !ok || username == "" {
+			return nil, errors.New("-basic-auth must be user:password")
+		}
+		options = append(options, api.WithBasicAuth(username, password))
+	case f.tokenHeader != "":
+		return nil, errors.New("-token-header needs -token-env or -token-file")
+	}
+
+	return options, nil
+}
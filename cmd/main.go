@@ -1,26 +1,76 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"torus-neighbors/internal/api"
+	"torus-neighbors/internal/domain"
 	"torus-neighbors/internal/service"
 )
 
 const (
 	defaultAPIURL = "https://zadanie.openmed.sk"
 	defaultUser   = ""
+
+	recordEnv    = "TORUS_RECORD"
+	replayEnv    = "TORUS_REPLAY"
+	basicAuthEnv = "TORUS_BASIC_AUTH"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve-mock" {
+		if err := runServeMock(os.Args[2:]); err != nil {
+			log.Fatalf("Mock server failed: %v", err)
+		}
+		return
+	}
+
 	var (
 		apiURL    = flag.String("api", defaultAPIURL, "API base URL")
 		user      = flag.String("user", defaultUser, "User identifier")
 		validate  = flag.Bool("validate", false, "Run local validation only (no API calls)")
+		hashAlg   = flag.String("hash-alg", string(domain.SHA256), "Matrix hash algorithm")
+		hashEnc   = flag.String("hash-encoding", string(domain.EncodingBase64), "Matrix hash encoding")
+		padding   = flag.String("padding", "1", "Extended matrix border: k or top,right,bottom,left")
+		workers   = flag.Int("hash-workers", 0, "Goroutines hashing the matrix in parallel (0 = sequential)")
+		pingTO    = flag.Duration("ping-timeout", 0, "Deadline for the ping phase (0 = none)")
+		getTO     = flag.Duration("challenge-timeout", 0, "Deadline for the challenge request phase (0 = none)")
+		submitTO  = flag.Duration("submit-timeout", 0, "Deadline for the submission phase (0 = none)")
+		attempts  = flag.Int("retries", api.DefaultRetryPolicy().MaxAttempts, "Maximum attempts per API request (1 = no retries)")
+		record    = flag.String("record", os.Getenv(recordEnv), "Record API traffic to this cassette file")
+		replay    = flag.String("replay", os.Getenv(replayEnv), "Replay API traffic from this cassette file")
+		redact    = flag.String("redact", "user,uuid", "Values to redact on cassettes: user, uuid or none")
+		method    = flag.String("challenge-method", string(api.ChallengeMethodAuto), "How to request challenges: auto, post, query or get-body")
+		strict    = flag.Bool("strict", false, "Reject challenge responses with unknown fields")
+		kindName  = flag.String("kind", service.EasyChallenge.Name, "Challenge kind: "+service.ChallengeKindNames())
 		showUsage = flag.Bool("help", false, "Show usage information")
+		rate      = flag.Float64("rate", 0, "Maximum API requests per second (0 = unlimited)")
+		burst     = flag.Int("burst", 1, "Requests allowed in a burst above -rate")
+		tripAfter = flag.Int("breaker-threshold", 0, "Consecutive failures that open an endpoint's circuit breaker (0 = no breaker)")
+		cooldown  = flag.Duration("breaker-cooldown", api.DefaultBreakerConfig().Cooldown, "How long an open circuit breaker fails fast before probing")
+		auth      authFlags
+		transport transportFlags
 	)
+	flag.Var(&auth.headers, "header", `Extra request header "Key: Value" (repeatable)`)
+	flag.Var(&auth.secretHeaders, "secret-header", `Like -header, but the value is masked in HTTP traces`)
+	flag.StringVar(&auth.userAgent, "user-agent", "", "User-Agent header for API requests")
+	flag.StringVar(&auth.tokenEnv, "token-env", "", "Read the API token from this environment variable")
+	flag.StringVar(&auth.tokenFile, "token-file", "", "Read the API token from this file")
+	flag.StringVar(&auth.tokenHeader, "token-header", "", "Send the token verbatim in this header instead of as a bearer token")
+	flag.StringVar(&auth.basicAuth, "basic-auth", os.Getenv(basicAuthEnv), "HTTP basic auth as user:password (env: "+basicAuthEnv+")")
+	flag.StringVar(&transport.caFile, "ca-file", "", "PEM bundle of extra trusted CAs")
+	flag.StringVar(&transport.certFile, "cert", "", "PEM client certificate for mutual TLS")
+	flag.StringVar(&transport.keyFile, "key", "", "PEM key of the client certificate")
+	flag.StringVar(&transport.minTLS, "tls-min", "", "Minimum TLS version: 1.0, 1.1, 1.2 or 1.3")
+	flag.StringVar(&transport.proxyURL, "proxy", "", "Proxy URL, overriding HTTP_PROXY/HTTPS_PROXY")
+	flag.StringVar(&transport.noProxy, "no-proxy", "", "Comma separated hosts, domains or CIDRs that bypass the proxy")
 
 	flag.Parse()
 
@@ -29,15 +79,97 @@ func main() {
 		return
 	}
 
+	algorithm, err := domain.ParseHashAlgorithm(*hashAlg)
+	if err != nil {
+		log.Fatalf("Invalid -hash-alg: %v", err)
+	}
+	encoding, err := domain.ParseDigestEncoding(*hashEnc)
+	if err != nil {
+		log.Fatalf("Invalid -hash-encoding: %v", err)
+	}
+
+	border, err := domain.ParsePadding(*padding)
+	if err != nil {
+		log.Fatalf("Invalid -padding: %v", err)
+	}
+
+	kind, err := service.ParseChallengeKind(*kindName)
+	if err != nil {
+		log.Fatalf("Invalid -kind: %v", err)
+	}
+
 	// Initialize services
-	apiClient := api.NewClient(*apiURL)
-	solver := service.NewTorusChallengeSolver(apiClient)
+	retryPolicy := api.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = *attempts
+	submitRetryPolicy := api.DefaultSubmitRetryPolicy()
+	submitRetryPolicy.MaxAttempts = *attempts
+
+	clientOptions := []api.ClientOption{
+		api.WithRetryPolicy(retryPolicy),
+		api.WithSubmitRetryPolicy(submitRetryPolicy),
+	}
+	authOptions, err := auth.clientOptions()
+	if err != nil {
+		log.Fatalf("Invalid authentication options: %v", err)
+	}
+	clientOptions = append(clientOptions, authOptions...)
+	transportOption, err := transport.clientOption()
+	if err != nil {
+		log.Fatalf("Invalid TLS or proxy options: %v", err)
+	}
+	if transportOption != nil {
+		clientOptions = append(clientOptions, transportOption)
+	}
+
+	challengeMethod, err := api.ParseChallengeMethod(*method)
+	if err != nil {
+		log.Fatalf("Invalid -challenge-method: %v", err)
+	}
+	clientOptions = append(clientOptions, api.WithChallengeMethod(challengeMethod))
+	if *strict {
+		clientOptions = append(clientOptions, api.WithDisallowUnknownFields())
+	}
+
+	var rateLimiter *api.RateLimiter
+	if *rate > 0 {
+		if rateLimiter, err = api.NewRateLimiter(*rate, *burst); err != nil {
+			log.Fatalf("Invalid -rate: %v", err)
+		}
+		clientOptions = append(clientOptions, api.WithRateLimiter(rateLimiter))
+	}
+	if *tripAfter > 0 {
+		breakers := api.NewCircuitBreakers(api.BreakerConfig{FailureThreshold: *tripAfter, Cooldown: *cooldown})
+		clientOptions = append(clientOptions, api.WithCircuitBreakers(breakers))
+	}
+	cassetteOption, err := cassetteOption(*record, *replay, *redact)
+	if err != nil {
+		log.Fatalf("Invalid cassette options: %v", err)
+	}
+	if cassetteOption != nil {
+		clientOptions = append(clientOptions, cassetteOption)
+	}
+
+	apiClient := api.NewClient(*apiURL, clientOptions...)
+	defer closeClient(apiClient)
+	solver, err := service.NewTorusChallengeSolverWithConfig(apiClient, service.SolverConfig{
+		Hash:        domain.HasherConfig{Algorithm: algorithm, Encoding: encoding, Padding: &border},
+		HashWorkers: *workers,
+		Timeouts: service.PhaseTimeouts{
+			Ping:      *pingTO,
+			Challenge: *getTO,
+			Submit:    *submitTO,
+		},
+		Kind: kind,
+	})
+	if err != nil {
+		fatalf(apiClient, "Failed to configure solver: %v", err)
+	}
 
 	// Run local validation if requested
 	if *validate {
 		fmt.Println("Running local validation...")
 		if err := solver.ValidateLocalExample(); err != nil {
-			log.Fatalf("Local validation failed: %v", err)
+			fatalf(apiClient, "Local validation failed: %v", err)
 		}
 		fmt.Println("Local validation completed successfully!")
 		return
@@ -52,14 +184,85 @@ func main() {
 	// First run local validation to ensure our implementation is correct
 	fmt.Println("Running local validation before API interaction...")
 	if err := solver.ValidateLocalExample(); err != nil {
-		log.Fatalf("Local validation failed: %v", err)
+		fatalf(apiClient, "Local validation failed: %v", err)
 	}
 	fmt.Println()
 
-	// Now solve the actual challenge from API
-	if err := solver.SolveChallenge(*user); err != nil {
-		log.Fatalf("Challenge failed: %v", err)
+	// Now solve the actual challenge from API; Ctrl-C aborts in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	attempt, err := solver.SolveChallengeContext(ctx, *user)
+	if rateLimiter != nil {
+		fmt.Printf("Rate limiter: %s\n", rateLimiter.Stats())
+	}
+	if err != nil {
+		for endpoint, state := range solver.CircuitBreakerStates() {
+			if state != api.BreakerClosed {
+				log.Printf("Circuit breaker for %s is %s", endpoint, state)
+			}
+		}
+		var phaseErr *service.PhaseError
+		if errors.As(err, &phaseErr) && phaseErr.Cancelled() {
+			log.Printf("Challenge cancelled during %s phase: %v", phaseErr.Phase, err)
+		} else {
+			log.Printf("Challenge failed: %v", err)
+		}
+		stop()
+		closeClient(apiClient)
+		os.Exit(service.ExitCode(err))
+	}
+	if attempt.Submission.Verdict == api.VerdictRejected {
+		stop()
+		closeClient(apiClient)
+		os.Exit(service.ExitWrongAnswer)
+	}
+}
+
+// closeClient flushes the HTTP trace; os.Exit skips deferred calls, so exit
+// paths call it explicitly.
+func closeClient(client *api.Client) {
+	if err := client.Close(); err != nil {
+		log.Printf("Failed to close API client: %v", err)
+	}
+}
+
+// fatalf is log.Fatalf for use once the client exists: it closes the client
+// first so that the trace is not lost.
+func fatalf(client *api.Client, format string, args ...any) {
+	log.Printf(format, args...)
+	closeClient(client)
+	os.Exit(1)
+}
+
+// cassetteOption returns the client option for -record or -replay, or nil
+// when neither is set.
+func cassetteOption(recordPath, replayPath, redact string) (api.ClientOption, error) {
+	if recordPath != "" && replayPath != "" {
+		return nil, errors.New("-record and -replay are mutually exclusive")
+	}
+	if recordPath == "" && replayPath == "" {
+		return nil, nil
+	}
+
+	redaction, err := api.ParseRedaction(redact)
+	if err != nil {
+		return nil, err
+	}
+
+	if recordPath != "" {
+		return api.WithTransportWrapper(func(next http.RoundTripper) http.RoundTripper {
+			return api.NewRecordingTransport(next, recordPath, redaction)
+		}), nil
 	}
+
+	replay, err := api.NewReplayTransport(replayPath, redaction)
+	if err != nil {
+		return nil, err
+	}
+	return api.WithTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+		return replay
+	}), nil
 }
 
 func printUsage() {
@@ -73,11 +276,55 @@ This application solves the "Find the Cell's Neighbours" challenge by:
 
 Usage:
   %s [options]
+  %s serve-mock [-addr host:port] [-seed n] [-max-width n] [-max-height n]
 
 Options:
   -api <url>     API base URL (default: %s)
   -user <name>   User identifier for API requests (default: empty)
   -validate      Run local validation only, no API calls
+  -hash-alg <a>  Matrix hash algorithm: %s (default: %s)
+  -hash-encoding <e>
+                 Matrix hash encoding: %s (default: %s)
+  -padding <k>   Extended matrix border, k or top,right,bottom,left (default: 1)
+  -hash-workers <n>
+                 Goroutines hashing the matrix in parallel, for large matrices
+                 (default: 0, sequential)
+  -ping-timeout <d>, -challenge-timeout <d>, -submit-timeout <d>
+                 Per-phase deadlines such as 5s (default: none)
+  -retries <n>   Maximum attempts per API request, 1 disables retries (default: %d)
+  -kind <k>      Challenge kind: %s (default: %s)
+  -challenge-method <m>
+                 How to request challenges: post (JSON body), query (GET with URL
+                 parameters), get-body (GET with JSON body) or auto, which tries
+                 them in that order (default: auto)
+  -strict        Reject challenge responses with fields the solver does not know
+  -rate <r>, -burst <n>
+                 Limit API requests to r per second with bursts of n (default: unlimited)
+  -breaker-threshold <n>, -breaker-cooldown <d>
+                 Fail fast on an endpoint after n consecutive failures, probing
+                 again after d (default: no breaker, %s cooldown)
+  -header "K: V" Extra request header, repeatable; shown in HTTP traces
+  -secret-header "K: V"
+                 Extra request header for credentials, masked in HTTP traces
+  -user-agent <s>
+                 User-Agent header for API requests
+  -token-env <name>, -token-file <path>
+                 Send a bearer token read from an environment variable or file
+  -token-header <name>
+                 Send the token in this header instead, e.g. X-API-Key
+  -basic-auth <user:password>
+                 HTTP basic auth (env: %s)
+  -ca-file <pem> Trust the CAs in this bundle in addition to the system roots
+  -cert <pem>, -key <pem>
+                 Client certificate and key for mutual TLS
+  -tls-min <v>   Minimum TLS version: 1.0, 1.1, 1.2 or 1.3
+  -proxy <url>   Proxy for API requests (default: HTTP_PROXY/HTTPS_PROXY)
+  -no-proxy <list>
+                 Comma separated hosts, .domains or CIDRs reached directly
+  -record <file> Record API traffic to a cassette file (env: %s)
+  -replay <file> Serve API traffic from a cassette file, no network (env: %s)
+  -redact <list> Values replaced by placeholders on cassettes: user,uuid or none
+                 (default: user,uuid)
   -help          Show this help message
 
 Examples:
@@ -90,6 +337,23 @@ Examples:
   # Use custom API URL
   %s -api "https://custom-api.com" -user "your-name"
 
+  # Record a session, then replay it offline
+  %s -user "your-name" -record session.json
+  %s -user "your-name" -replay session.json
+
+  # Solve against a local stand-in server
+  %s serve-mock -addr 127.0.0.1:8080 -seed 42 &
+  %s -api "http://127.0.0.1:8080" -user "your-name"
+
+Environment:
+  DEBUG_HTTP         Trace HTTP traffic: stderr, file:<path> or har:<path>
+  DEBUG_HTTP_REDACT  Extra trace redaction: header:<name>,body:<field>,...
+
+Exit codes:
+  0 success, 1 other failure, 3 request rejected (4xx), 4 server error (5xx),
+  5 network failure, 6 undecodable or invalid response, 7 unsolvable challenge,
+  8 answer rejected by the server, 9 circuit breaker open, 130 cancelled
+
 The application will:
 1. Generate a UUID v4 for the attempt
 2. Test API connectivity with /ping
@@ -98,5 +362,9 @@ The application will:
 5. Submit the solution back to the API
 
 For more information about the problem, see the challenge description.
-`, os.Args[0], defaultAPIURL, os.Args[0], os.Args[0], os.Args[0])
+`, os.Args[0], os.Args[0], defaultAPIURL,
+		domain.HashAlgorithmNames(), domain.SHA256, domain.DigestEncodingNames(), domain.EncodingBase64,
+		api.DefaultRetryPolicy().MaxAttempts, service.ChallengeKindNames(), service.EasyChallenge.Name,
+		api.DefaultBreakerConfig().Cooldown, basicAuthEnv, recordEnv, replayEnv,
+		os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"torus-neighbors/internal/api"
+	"torus-neighbors/internal/mockserver"
+)
+
+func runServeMock(args []string) error {
+	flags := flag.NewFlagSet("serve-mock", flag.ExitOnError)
+	var (
+		addr      = flags.String("addr", "127.0.0.1:8080", "Listen address")
+		seed      = flags.Int64("seed", 0, "Seed for issued challenges (0 = random)")
+		maxWidth  = flags.Int("max-width", 10, "Maximum width of issued challenges")
+		maxHeight = flags.Int("max-height", 10, "Maximum height of issued challenges")
+		forms     = flags.String("accept", "", "Comma separated challenge request forms to accept: post, query, get-body (default: all)")
+	)
+	flags.Parse(args)
+
+	challengeForms, err := parseChallengeForms(*forms)
+	if err != nil {
+		return fmt.Errorf("invalid -accept: %w", err)
+	}
+	server := mockserver.NewServer(mockserver.Config{
+		Seed:           *seed,
+		MaxWidth:       *maxWidth,
+		MaxHeight:      *maxHeight,
+		ChallengeForms: challengeForms,
+	})
+
+	fmt.Printf("Mock challenge server listening on http://%s\n", *addr)
+	return http.ListenAndServe(*addr, server)
+}
+
+// parseChallengeForms splits a comma separated list of post, query and
+// get-body; empty means all of them.
+func parseChallengeForms(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var forms []string
+	for _, form := range strings.Split(value, ",") {
+		form = strings.TrimSpace(form)
+		if !slices.Contains(api.ChallengeFallbackOrder, api.ChallengeMethod(form)) {
+			return nil, fmt.Errorf("unknown challenge request form %q, expected post, query or get-body", form)
+		}
+		forms = append(forms, form)
+	}
+	return forms, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"torus-neighbors/internal/api"
+)
+
+type transportFlags struct {
+	caFile   string
+	certFile string
+	keyFile  string
+	minTLS   string
+	proxyURL string
+	noProxy  string
+}
+
+// clientOption returns the option installing a custom transport, or nil when
+// every flag is at its default.
+func (f *transportFlags) clientOption() (api.ClientOption, error) {
+	if *f == (transportFlags{}) {
+		return nil, nil
+	}
+
+	minVersion, err := api.ParseTLSVersion(f.minTLS)
+	if err != nil {
+		return nil, err
+	}
+
+	var noProxy []string
+	for _, host := range strings.Split(f.noProxy, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			noProxy = append(noProxy, host)
+		}
+	}
+
+	transport, err := api.NewTransport(api.TransportConfig{
+		CAFile:        f.caFile,
+		CertFile:      f.certFile,
+		KeyFile:       f.keyFile,
+		MinTLSVersion: minVersion,
+		ProxyURL:      f.proxyURL,
+		NoProxy:       noProxy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.WithTransport(transport), nil
+}
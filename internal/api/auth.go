@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TokenProvider supplies credentials for each request. refresh is true when
+// the server rejected the previous token with 401 Unauthorized.
+type TokenProvider interface {
+	Token(ctx context.Context, refresh bool) (string, error)
+}
+
+type TokenFunc func(ctx context.Context) (string, error)
+
+// EnvToken reads the token from an environment variable on every request.
+func EnvToken(name string) TokenProvider {
+	return envToken(name)
+}
+
+type envToken string
+
+func (e envToken) Token(context.Context, bool) (string, error) {
+	token := os.Getenv(string(e))
+	if token == "" {
+		return "", fmt.Errorf("environment variable %s is not set", string(e))
+	}
+	return token, nil
+}
+
+// FileToken reads the token from a file on every request, so rotated tokens
+// are picked up without a restart.
+func FileToken(path string) TokenProvider {
+	return fileToken(path)
+}
+
+type fileToken string
+
+func (f fileToken) Token(context.Context, bool) (string, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", string(f))
+	}
+	return token, nil
+}
+
+// CachedToken calls fetch for the first request and again whenever the server
+// rejects the cached token.
+func CachedToken(fetch TokenFunc) TokenProvider {
+	return &cachedToken{fetch: fetch}
+}
+
+type cachedToken struct {
+	mu    sync.Mutex
+	fetch TokenFunc
+	token string
+}
+
+func (c *cachedToken) Token(ctx context.Context, refresh bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == "" || refresh {
+		token, err := c.fetch(ctx)
+		if err != nil {
+			return "", err
+		}
+		c.token = token
+	}
+	return c.token, nil
+}
+
+// authenticator adds credentials to an outgoing request.
+type authenticator interface {
+	authenticate(ctx context.Context, req *http.Request, refresh bool) error
+	// refreshable reports whether a 401 is worth another attempt with fresh
+	// credentials.
+	refreshable() bool
+	// secretHeader names the header carrying the credentials, which traces
+	// must mask.
+	secretHeader() string
+}
+
+type tokenAuth struct {
+	provider TokenProvider
+	header   string
+	scheme   string
+}
+
+func (a tokenAuth) authenticate(ctx context.Context, req *http.Request, refresh bool) error {
+	token, err := a.provider.Token(ctx, refresh)
+	if err != nil {
+		return err
+	}
+	if a.scheme != "" {
+		token = a.scheme + " " + token
+	}
+	req.Header.Set(a.header, token)
+	return nil
+}
+
+func (a tokenAuth) refreshable() bool { return true }
+
+func (a tokenAuth) secretHeader() string { return a.header }
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+func (a basicAuth) authenticate(_ context.Context, req *http.Request, _ bool) error {
+	req.SetBasicAuth(a.username, a.password)
+	return nil
+}
+
+func (a basicAuth) refreshable() bool { return false }
+
+func (a basicAuth) secretHeader() string { return "Authorization" }
+
+// WithBearerToken sends "Authorization: Bearer <token>" with every request.
+func WithBearerToken(provider TokenProvider) ClientOption {
+	return func(c *Client) {
+		c.auth = tokenAuth{provider: provider, header: "Authorization", scheme: "Bearer"}
+	}
+}
+
+// WithAPIKey sends the provided key verbatim in header, e.g. X-API-Key. The
+// header is masked in traces like Authorization.
+func WithAPIKey(header string, provider TokenProvider) ClientOption {
+	return func(c *Client) {
+		c.auth = tokenAuth{provider: provider, header: header}
+	}
+}
+
+func WithBasicAuth(username, password string) ClientOption {
+	return func(c *Client) {
+		c.auth = basicAuth{username: username, password: password}
+	}
+}
+
+// WithHeader adds a header to every request. Repeated calls for the same key
+// add further values. The value shows in HTTP traces; use WithSecretHeader
+// for credentials.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Add(key, value)
+	}
+}
+
+// WithSecretHeader is WithHeader for credentials: the value is masked in HTTP
+// traces.
+func WithSecretHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		WithHeader(key, value)(c)
+		c.secretHeaders = append(c.secretHeaders, key)
+	}
+}
+
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Set("User-Agent", userAgent)
+	}
+}
+
+// ParseHeader parses "Key: Value" as accepted by curl's -H.
+func ParseHeader(value string) (key, headerValue string, err error) {
+	key, headerValue, ok := strings.Cut(value, ":")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", errors.New(`header must look like "Key: Value"`)
+	}
+	return http.CanonicalHeaderKey(key), strings.TrimSpace(headerValue), nil
+}
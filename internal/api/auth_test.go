@@ -0,0 +1,258 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStaticHeadersAndUserAgent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "torus-test/1.0" {
+			t.Errorf("Expected User-Agent torus-test/1.0, got %q", r.Header.Get("User-Agent"))
+		}
+		if values := r.Header.Values("X-Team"); len(values) != 2 || values[0] != "a" || values[1] != "b" {
+			t.Errorf("Expected X-Team a and b, got %v", values)
+		}
+		if r.Method == http.MethodPost && r.Header.Get("Idempotency-Key") != "test-uuid" {
+			t.Errorf("Per-request headers should still be sent, got %v", r.Header)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL,
+		WithUserAgent("torus-test/1.0"),
+		WithHeader("X-Team", "a"),
+		WithHeader("X-Team", "b"),
+	)
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if _, err := client.SubmitSolution("test-uuid", "0", "hash"); err != nil {
+		t.Fatalf("SubmitSolution failed: %v", err)
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewClient(server.URL, WithBasicAuth("alice", "s3cret")).Ping(); err != nil {
+		t.Errorf("Ping with basic auth failed: %v", err)
+	}
+
+	err := NewClient(server.URL, WithBasicAuth("alice", "wrong")).Ping()
+	if !isUnauthorized(err) {
+		t.Errorf("Expected 401 with wrong password, got %v", err)
+	}
+}
+
+func TestBearerTokenFromEnvAndFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("TORUS_TEST_TOKEN", "token-1")
+	if err := NewClient(server.URL, WithBearerToken(EnvToken("TORUS_TEST_TOKEN"))).Ping(); err != nil {
+		t.Errorf("Ping with env token failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(path, []byte("token-1\n"), 0o600)
+	if err := NewClient(server.URL, WithBearerToken(FileToken(path))).Ping(); err != nil {
+		t.Errorf("Ping with file token failed: %v", err)
+	}
+}
+
+func TestAPIKeyHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key-1" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	provider := CachedToken(func(context.Context) (string, error) { return "key-1", nil })
+	if err := NewClient(server.URL, WithAPIKey("X-API-Key", provider)).Ping(); err != nil {
+		t.Errorf("Ping with API key failed: %v", err)
+	}
+}
+
+func TestCachedTokenRefreshedOnUnauthorized(t *testing.T) {
+	var current atomic.Value
+	current.Store("fresh")
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer "+current.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	var fetches atomic.Int32
+	provider := CachedToken(func(context.Context) (string, error) {
+		fetches.Add(1)
+		return current.Load().(string), nil
+	})
+	client := NewClient(server.URL, WithBearerToken(provider), WithSubmitRetryPolicy(NoRetry()))
+
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("Token should be cached, fetched %d times", fetches.Load())
+	}
+
+	// The server rotates the token; the client refreshes once and succeeds,
+	// even for a submission.
+	current.Store("rotated")
+	requests.Store(0)
+	if _, err := client.SubmitSolution("uuid", "0", "hash"); err != nil {
+		t.Fatalf("SubmitSolution should succeed after refreshing the token, got %v", err)
+	}
+	if fetches.Load() != 2 || requests.Load() != 2 {
+		t.Errorf("Expected one refresh and two requests, got %d fetches and %d requests", fetches.Load(), requests.Load())
+	}
+}
+
+func TestRetriesAfterRefreshReuseToken(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusUnauthorized)
+		case 2, 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var fetches atomic.Int32
+	provider := CachedToken(func(context.Context) (string, error) {
+		fetches.Add(1)
+		return "token", nil
+	})
+	client := NewClient(server.URL, WithBearerToken(provider), WithRetryPolicy(fastRetryPolicy(3)))
+
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping should succeed after the refresh and two retries, got %v", err)
+	}
+	if requests.Load() != 4 || fetches.Load() != 2 {
+		t.Errorf("Expected 4 requests and only the initial fetch plus one refresh, got %d requests and %d fetches",
+			requests.Load(), fetches.Load())
+	}
+}
+
+func TestTokenRefreshIsAttemptedOnce(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	provider := CachedToken(func(context.Context) (string, error) { return "never-valid", nil })
+	err := NewClient(server.URL, WithBearerToken(provider)).Ping()
+	if !isUnauthorized(err) {
+		t.Errorf("Expected 401, got %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("Expected the original request and one refresh, got %d requests", requests.Load())
+	}
+}
+
+func TestMissingTokenIsNotRetried(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	t.Setenv("TORUS_TEST_TOKEN", "")
+	err := NewClient(server.URL, WithBearerToken(EnvToken("TORUS_TEST_TOKEN"))).Ping()
+
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Expected AuthError, got %T: %v", err, err)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("No request should be sent without credentials, got %d", requests.Load())
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		value string
+		key   string
+		val   string
+		valid bool
+	}{
+		{"X-Team: core", "X-Team", "core", true},
+		{"x-api-key:abc:def", "X-Api-Key", "abc:def", true},
+		{"Empty:", "Empty", "", true},
+		{"no colon", "", "", false},
+		{": value", "", "", false},
+	}
+
+	for _, tt := range tests {
+		key, val, err := ParseHeader(tt.value)
+		if (err == nil) != tt.valid || key != tt.key || val != tt.val {
+			t.Errorf("ParseHeader(%q) = %q, %q, %v", tt.value, key, val, err)
+		}
+	}
+}
+
+func isUnauthorized(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized
+}
+
+func TestAPIKeyMaskedInTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "k3y-s3cret" {
+			t.Errorf("Expected the real key on the wire, got %q", r.Header.Get("X-Api-Key"))
+		}
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	redaction := DefaultTraceRedaction()
+	client := NewClient(server.URL,
+		WithAPIKey("X-API-Key", CachedToken(func(context.Context) (string, error) { return "k3y-s3cret", nil })),
+		WithSecretHeader("X-Team-Key", "t3am-s3cret"),
+		WithHeader("X-Team", "core"),
+		WithTrace(NewTextTraceSink(&buf), redaction),
+	)
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	client.Close()
+
+	if trace := buf.String(); strings.Contains(trace, "k3y-s3cret") || !strings.Contains(trace, "X-Api-Key: "+redactedValue) {
+		t.Errorf("API key should be masked in the trace:\n%s", trace)
+	}
+	if trace := buf.String(); strings.Contains(trace, "t3am-s3cret") || !strings.Contains(trace, "X-Team: core") {
+		t.Errorf("Only the secret header should be masked in the trace:\n%s", trace)
+	}
+	if len(redaction.Headers) != len(DefaultTraceRedaction().Headers) {
+		t.Errorf("The caller's redaction should not be modified, got %v", redaction.Headers)
+	}
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type BreakerState int
+
+const (
+	// BreakerClosed lets requests through and counts consecutive failures.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails requests immediately until the cooldown has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe through; its outcome closes or
+	// reopens the breaker.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed attempts that
+	// opens the breaker. Transport errors and 5xx responses are failures.
+	FailureThreshold int
+	// Cooldown is how long the breaker stays open before half-opening.
+	Cooldown time.Duration
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{FailureThreshold: 5, Cooldown: 30 * time.Second}
+}
+
+// CircuitOpenError is returned without contacting the server while the
+// endpoint's breaker is open, or half-open with a probe already in flight.
+type CircuitOpenError struct {
+	Endpoint string
+	State    BreakerState
+	// RetryAfter is the remaining cooldown; 0 while a probe is in flight.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.State == BreakerHalfOpen {
+		return fmt.Sprintf("%s: circuit breaker half-open, probe in flight", e.Endpoint)
+	}
+	return fmt.Sprintf("%s: circuit breaker open, retry in %v", e.Endpoint, e.RetryAfter.Round(time.Millisecond))
+}
+
+// CircuitBreakers keeps one breaker per endpoint ("GET /ping", "POST
+// /challenge-me-easy", ...). Share it between clients talking to the same
+// host so that they trip together. It is safe for concurrent use.
+type CircuitBreakers struct {
+	config BreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+type circuitBreaker struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreakers(config BreakerConfig) *CircuitBreakers {
+	defaults := DefaultBreakerConfig()
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = defaults.Cooldown
+	}
+	return &CircuitBreakers{
+		config:   config,
+		now:      time.Now,
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+// WithCircuitBreakers guards every endpoint of the client with breakers.
+func WithCircuitBreakers(breakers *CircuitBreakers) ClientOption {
+	return func(c *Client) {
+		c.breakers = breakers
+	}
+}
+
+// State returns the state of the breaker for endpoint; endpoints that have not
+// been called yet are closed.
+func (b *CircuitBreakers) State(endpoint string) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if breaker, ok := b.breakers[endpoint]; ok {
+		return b.current(breaker)
+	}
+	return BreakerClosed
+}
+
+// States returns the state of every endpoint called so far.
+func (b *CircuitBreakers) States() map[string]BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make(map[string]BreakerState, len(b.breakers))
+	for endpoint, breaker := range b.breakers {
+		states[endpoint] = b.current(breaker)
+	}
+	return states
+}
+
+// current reports an open breaker whose cooldown has passed as half-open.
+func (b *CircuitBreakers) current(breaker *circuitBreaker) BreakerState {
+	if breaker.state == BreakerOpen && b.now().Sub(breaker.openedAt) >= b.config.Cooldown {
+		return BreakerHalfOpen
+	}
+	return breaker.state
+}
+
+// allow admits an attempt on endpoint or returns a *CircuitOpenError. A nil
+// *CircuitBreakers admits everything.
+func (b *CircuitBreakers) allow(endpoint string) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.breakers[endpoint]
+	if !ok {
+		breaker = &circuitBreaker{}
+		b.breakers[endpoint] = breaker
+	}
+
+	switch b.current(breaker) {
+	case BreakerOpen:
+		return &CircuitOpenError{
+			Endpoint:   endpoint,
+			State:      BreakerOpen,
+			RetryAfter: b.config.Cooldown - b.now().Sub(breaker.openedAt),
+		}
+	case BreakerHalfOpen:
+		if breaker.probing {
+			return &CircuitOpenError{Endpoint: endpoint, State: BreakerHalfOpen}
+		}
+		breaker.state = BreakerHalfOpen
+		breaker.probing = true
+	}
+	return nil
+}
+
+type attemptOutcome int
+
+const (
+	outcomeSuccess attemptOutcome = iota
+	outcomeFailure
+	// outcomeIgnored is used for attempts that say nothing about the
+	// server's health, e.g. cancelled ones.
+	outcomeIgnored
+)
+
+func classifyAttempt(ctx context.Context, status int, err error) attemptOutcome {
+	switch {
+	case err != nil && (ctx.Err() != nil || errors.Is(err, context.Canceled)):
+		return outcomeIgnored
+	case err != nil || status >= http.StatusInternalServerError:
+		return outcomeFailure
+	default:
+		return outcomeSuccess
+	}
+}
+
+func (b *CircuitBreakers) record(endpoint string, outcome attemptOutcome) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker := b.breakers[endpoint]
+	wasProbe := breaker.probing
+	breaker.probing = false
+
+	switch outcome {
+	case outcomeSuccess:
+		breaker.state = BreakerClosed
+		breaker.failures = 0
+	case outcomeFailure:
+		breaker.failures++
+		if wasProbe || breaker.failures >= b.config.FailureThreshold {
+			breaker.state = BreakerOpen
+			breaker.openedAt = b.now()
+		}
+	case outcomeIgnored:
+		if wasProbe {
+			// Let the next request probe instead.
+			breaker.state = BreakerOpen
+		}
+	}
+}
+
+// CircuitBreakerStates reports the breaker state per endpoint, or nil when
+// the client has no breakers.
+func (c *Client) CircuitBreakerStates() map[string]BreakerState {
+	if c.breakers == nil {
+		return nil
+	}
+	return c.breakers.States()
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock lets tests move the breakers' cooldown forward.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestBreakers(threshold int) (*CircuitBreakers, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breakers := NewCircuitBreakers(BreakerConfig{FailureThreshold: threshold, Cooldown: time.Minute})
+	breakers.now = clock.Now
+	return breakers, clock
+}
+
+func TestCircuitBreakerLifecycle(t *testing.T) {
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	breakers, clock := newTestBreakers(3)
+	client := NewClient(server.URL, WithCircuitBreakers(breakers), WithRetryPolicy(NoRetry()))
+
+	for i := 0; i < 3; i++ {
+		var statusErr *StatusError
+		if err := client.Ping(); !errors.As(err, &statusErr) {
+			t.Fatalf("Attempt %d: expected status error, got %v", i+1, err)
+		}
+	}
+	if state := breakers.State("GET /ping"); state != BreakerOpen {
+		t.Fatalf("Expected open breaker after 3 failures, got %s", state)
+	}
+
+	// Open: fail fast without contacting the server.
+	err := client.Ping()
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || openErr.Endpoint != "GET /ping" || openErr.RetryAfter != time.Minute {
+		t.Fatalf("Expected CircuitOpenError with full cooldown, got %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("Open breaker should not send requests, server saw %d", requests.Load())
+	}
+
+	// Other endpoints have their own breaker.
+	if state := breakers.State("GET /challenge-me-easy"); state != BreakerClosed {
+		t.Errorf("Challenge endpoint should be unaffected, got %s", state)
+	}
+
+	// After the cooldown a failing probe reopens it straight away.
+	clock.now = clock.now.Add(time.Minute)
+	if state := breakers.State("GET /ping"); state != BreakerHalfOpen {
+		t.Fatalf("Expected half-open after cooldown, got %s", state)
+	}
+	if err := client.Ping(); errors.As(err, &openErr) {
+		t.Fatalf("Half-open breaker should let a probe through, got %v", err)
+	}
+	if state := breakers.State("GET /ping"); state != BreakerOpen {
+		t.Fatalf("Failed probe should reopen the breaker, got %s", state)
+	}
+
+	// A successful probe closes it.
+	clock.now = clock.now.Add(time.Minute)
+	healthy.Store(true)
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Probe should succeed, got %v", err)
+	}
+	if states := client.CircuitBreakerStates(); states["GET /ping"] != BreakerClosed {
+		t.Errorf("Expected closed breaker after a successful probe, got %v", states)
+	}
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	breakers, clock := newTestBreakers(1)
+	breakers.allow("GET /ping")
+	breakers.record("GET /ping", outcomeFailure)
+	clock.now = clock.now.Add(time.Minute)
+
+	if err := breakers.allow("GET /ping"); err != nil {
+		t.Fatalf("First request after cooldown should probe, got %v", err)
+	}
+	var openErr *CircuitOpenError
+	if err := breakers.allow("GET /ping"); !errors.As(err, &openErr) || openErr.State != BreakerHalfOpen {
+		t.Errorf("Second request during the probe should fail fast, got %v", err)
+	}
+
+	// An inconclusive probe frees the slot for the next request.
+	breakers.record("GET /ping", outcomeIgnored)
+	if err := breakers.allow("GET /ping"); err != nil {
+		t.Errorf("Next request should probe after an ignored one, got %v", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	breakers, _ := newTestBreakers(2)
+	client := NewClient(server.URL, WithCircuitBreakers(breakers))
+	for i := 0; i < 5; i++ {
+		client.SubmitSolution("uuid", "0", "hash")
+	}
+
+	if state := breakers.State("POST /challenge-me-easy"); state != BreakerClosed {
+		t.Errorf("4xx responses should not trip the breaker, got %s", state)
+	}
+}
+
+func TestCircuitBreakerCountsRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	breakers, _ := newTestBreakers(2)
+	client := NewClient(server.URL, WithCircuitBreakers(breakers), WithRetryPolicy(fastRetryPolicy(5)))
+
+	var openErr *CircuitOpenError
+	if _, err := client.GetChallenge("uuid", "user"); !errors.As(err, &openErr) {
+		t.Errorf("Expected the breaker to cut the retries short, got %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("Expected 2 attempts before the breaker opened, got %d", requests.Load())
+	}
+}
+
+func TestClientWithoutBreakers(t *testing.T) {
+	if states := NewClient("http://localhost").CircuitBreakerStates(); states != nil {
+		t.Errorf("Expected no breaker states, got %v", states)
+	}
+}
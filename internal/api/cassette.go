@@ -0,0 +1,337 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrCassetteMismatch is returned by a ReplayTransport for requests that are
+// not on the cassette.
+var ErrCassetteMismatch = errors.New("request not found on cassette")
+
+// Cassette is a recorded sequence of API round trips.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+func (c *Cassette) Save(path string) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c); err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Redaction selects which values are replaced by placeholders on the
+// cassette. Placeholders are numbered in order of first appearance, so a
+// replayed session with fresh values maps onto the recording as long as it
+// makes the same requests.
+type Redaction struct {
+	User  bool
+	UUIDs bool
+}
+
+func DefaultRedaction() Redaction {
+	return Redaction{User: true, UUIDs: true}
+}
+
+// ParseRedaction parses a comma separated list of "user" and "uuid", or
+// "none".
+func ParseRedaction(value string) (Redaction, error) {
+	var redaction Redaction
+	if value == "none" || value == "" {
+		return redaction, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		switch strings.TrimSpace(part) {
+		case "user":
+			redaction.User = true
+		case "uuid":
+			redaction.UUIDs = true
+		default:
+			return Redaction{}, fmt.Errorf("unknown redaction %q (expected user, uuid or none)", part)
+		}
+	}
+	return redaction, nil
+}
+
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+const userPlaceholder = "<USER>"
+
+// redactor maps sensitive values to placeholders and back.
+type redactor struct {
+	redaction Redaction
+	values    map[string]string // value -> placeholder
+	originals map[string]string // placeholder -> value
+	uuids     int
+}
+
+func newRedactor(redaction Redaction) *redactor {
+	return &redactor{
+		redaction: redaction,
+		values:    make(map[string]string),
+		originals: make(map[string]string),
+	}
+}
+
+// learn picks up the user from a JSON request body or, for challenges
+// requested with query parameters, from the URL.
+func (r *redactor) learn(req *http.Request, body []byte) {
+	if !r.redaction.User {
+		return
+	}
+	var request struct {
+		User string `json:"user"`
+	}
+	if json.Unmarshal(body, &request) != nil || request.User == "" {
+		request.User = req.URL.Query().Get("user")
+	}
+	if request.User != "" {
+		r.values[request.User] = userPlaceholder
+		r.originals[userPlaceholder] = request.User
+	}
+}
+
+func (r *redactor) redact(s string) string {
+	if r.redaction.UUIDs {
+		s = uuidPattern.ReplaceAllStringFunc(s, func(uuid string) string {
+			placeholder, ok := r.values[uuid]
+			if !ok {
+				r.uuids++
+				placeholder = fmt.Sprintf("<UUID-%d>", r.uuids)
+				r.values[uuid] = placeholder
+				r.originals[placeholder] = uuid
+			}
+			return placeholder
+		})
+	}
+	if user, ok := r.originals[userPlaceholder]; ok {
+		s = strings.ReplaceAll(s, jsonString(user), jsonString(userPlaceholder))
+		s = strings.ReplaceAll(s, "user="+url.QueryEscape(user), "user="+url.QueryEscape(userPlaceholder))
+	}
+	return s
+}
+
+func (r *redactor) restore(s string) string {
+	for placeholder, value := range r.originals {
+		s = strings.ReplaceAll(s, placeholder, value)
+	}
+	return s
+}
+
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func (r *redactor) header(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for key, values := range header {
+		// The body length changes with the placeholders.
+		if key == "Content-Length" || key == "Date" {
+			continue
+		}
+		for _, value := range values {
+			redacted.Add(key, r.redact(value))
+		}
+	}
+	return redacted
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// RecordingTransport passes requests on to the wrapped transport and appends
+// every round trip to a cassette file, which is rewritten after each one.
+type RecordingTransport struct {
+	next     http.RoundTripper
+	path     string
+	mu       sync.Mutex
+	redactor *redactor
+	cassette Cassette
+}
+
+func NewRecordingTransport(next http.RoundTripper, path string, redaction Redaction) *RecordingTransport {
+	return &RecordingTransport{
+		next:     next,
+		path:     path,
+		redactor: newRedactor(redaction),
+	}
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.redactor.learn(req, reqBody)
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   t.redactor.redact(req.URL.RequestURI()),
+			Body:   t.redactor.redact(string(reqBody)),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     t.redactor.header(resp.Header),
+			Body:       t.redactor.redact(string(respBody)),
+		},
+	})
+	if err := t.cassette.Save(t.path); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ReplayTransport answers requests from a cassette without touching the
+// network. Each recorded interaction is served once, in order of matching.
+type ReplayTransport struct {
+	mu       sync.Mutex
+	redactor *redactor
+	cassette *Cassette
+	used     []bool
+}
+
+func NewReplayTransport(path string, redaction Redaction) (*ReplayTransport, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayTransport{
+		redactor: newRedactor(redaction),
+		cassette: cassette,
+		used:     make([]bool, len(cassette.Interactions)),
+	}, nil
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.redactor.learn(req, reqBody)
+	path := t.redactor.redact(req.URL.RequestURI())
+	body := t.redactor.redact(string(reqBody))
+
+	for i, interaction := range t.cassette.Interactions {
+		recorded := interaction.Request
+		if t.used[i] || recorded.Method != req.Method || recorded.Path != path || recorded.Body != body {
+			continue
+		}
+		t.used[i] = true
+
+		header := make(http.Header, len(interaction.Response.Header))
+		for key, values := range interaction.Response.Header {
+			for _, value := range values {
+				header.Add(key, t.redactor.restore(value))
+			}
+		}
+		respBody := t.redactor.restore(interaction.Response.Body)
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s %s", ErrCassetteMismatch, req.Method, path, body)
+}
+
+// Remaining returns the number of recorded interactions not replayed yet.
+func (t *ReplayTransport) Remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	remaining := 0
+	for _, used := range t.used {
+		if !used {
+			remaining++
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	recordedUUID = "11111111-2222-4333-8444-555555555555"
+	replayedUUID = "aaaaaaaa-bbbb-4ccc-8ddd-eeeeeeeeeeee"
+)
+
+func newEchoChallengeServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			UUID   string  `json:"uuid"`
+			User   string  `json:"user"`
+			Result *string `json:"result"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+
+		if request.Result != nil {
+			w.Write([]byte(`{"uuid":"` + request.UUID + `","accepted":true}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"uuid": request.UUID, "set_x": "4", "set_y": "4", "set_z": "5", "user": request.User})
+	}))
+}
+
+func recordSession(t *testing.T, path string, redaction Redaction) {
+	t.Helper()
+	server := newEchoChallengeServer()
+	defer server.Close()
+
+	client := NewClient(server.URL, WithTransportWrapper(func(next http.RoundTripper) http.RoundTripper {
+		return NewRecordingTransport(next, path, redaction)
+	}))
+	if _, err := client.GetChallenge(recordedUUID, "alice"); err != nil {
+		t.Fatalf("GetChallenge failed while recording: %v", err)
+	}
+	if _, err := client.SubmitSolution(recordedUUID, "0,1", "hash"); err != nil {
+		t.Fatalf("SubmitSolution failed while recording: %v", err)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	recordSession(t, path, DefaultRedaction())
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Cassette not written: %v", err)
+	}
+	if strings.Contains(string(data), "alice") || strings.Contains(string(data), recordedUUID) {
+		t.Errorf("Cassette should be redacted:\n%s", data)
+	}
+	if !strings.Contains(string(data), "<UUID-1>") || !strings.Contains(string(data), "<USER>") {
+		t.Errorf("Cassette should contain placeholders:\n%s", data)
+	}
+
+	replay, err := NewReplayTransport(path, DefaultRedaction())
+	if err != nil {
+		t.Fatalf("Failed to load cassette: %v", err)
+	}
+	client := NewClient("http://cassette.invalid",
+		WithRetryPolicy(NoRetry()),
+		WithTransportWrapper(func(http.RoundTripper) http.RoundTripper { return replay }),
+	)
+
+	var challenge map[string]string
+	request := ChallengeRequest{UUID: replayedUUID, User: "bob"}
+	if err := client.FetchChallengeContext(context.Background(), EasyChallengePath, request, &challenge); err != nil {
+		t.Fatalf("Fetching the challenge failed on replay: %v", err)
+	}
+	if challenge["uuid"] != replayedUUID || challenge["user"] != "bob" {
+		t.Errorf("Placeholders should be restored with live values, got %+v", challenge)
+	}
+
+	result, err := client.SubmitSolution(replayedUUID, "0,1", "hash")
+	if err != nil {
+		t.Fatalf("SubmitSolution failed on replay: %v", err)
+	}
+	if !result.Accepted() || result.Fields["uuid"] != replayedUUID {
+		t.Errorf("Unexpected replayed submission result %+v", result)
+	}
+	if replay.Remaining() != 0 {
+		t.Errorf("Expected all interactions replayed, %d left", replay.Remaining())
+	}
+
+	if err := client.Ping(); !errors.Is(err, ErrCassetteMismatch) {
+		t.Errorf("Expected cassette mismatch for unrecorded request, got %v", err)
+	}
+}
+
+func TestReplayRejectsDifferentRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	recordSession(t, path, DefaultRedaction())
+
+	replay, err := NewReplayTransport(path, DefaultRedaction())
+	if err != nil {
+		t.Fatalf("Failed to load cassette: %v", err)
+	}
+	client := NewClient("http://cassette.invalid",
+		WithSubmitRetryPolicy(NoRetry()),
+		WithTransportWrapper(func(http.RoundTripper) http.RoundTripper { return replay }),
+	)
+
+	if _, err := client.GetChallenge(replayedUUID, "bob"); err != nil {
+		t.Fatalf("GetChallenge failed on replay: %v", err)
+	}
+	if _, err := client.SubmitSolution(replayedUUID, "0,2", "hash"); !errors.Is(err, ErrCassetteMismatch) {
+		t.Errorf("Expected cassette mismatch for a different answer, got %v", err)
+	}
+}
+
+func TestRecordWithoutRedaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	recordSession(t, path, Redaction{})
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("Failed to load cassette: %v", err)
+	}
+	if len(cassette.Interactions) != 2 {
+		t.Fatalf("Expected 2 interactions, got %d", len(cassette.Interactions))
+	}
+	if !strings.Contains(cassette.Interactions[0].Request.Body, "alice") ||
+		!strings.Contains(cassette.Interactions[1].Response.Body, recordedUUID) {
+		t.Errorf("Values should be kept verbatim: %+v", cassette.Interactions)
+	}
+}
+
+func TestParseRedaction(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Redaction
+		valid    bool
+	}{
+		{"user,uuid", Redaction{User: true, UUIDs: true}, true},
+		{"uuid", Redaction{UUIDs: true}, true},
+		{"none", Redaction{}, true},
+		{"user,email", Redaction{}, false},
+	}
+
+	for _, tt := range tests {
+		actual, err := ParseRedaction(tt.value)
+		if (err == nil) != tt.valid || actual != tt.expected {
+			t.Errorf("ParseRedaction(%q) = %+v, %v", tt.value, actual, err)
+		}
+	}
+}
@@ -2,15 +2,24 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"net/http/httputil"
+	"net/http/httptrace"
+	"net/url"
 	"os"
+	"slices"
+	"sync"
 	"time"
 )
 
+// EasyChallengePath serves and grades the original 8-neighbour challenge.
+const EasyChallengePath = "/challenge-me-easy"
+
 type ChallengeRequest struct {
 	UUID string `json:"uuid"`
 	User string `json:"user"`
@@ -30,128 +39,406 @@ type SolutionRequest struct {
 }
 
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL        string
+	httpClient     *http.Client
+	retry          RetryPolicy
+	submitRetry    RetryPolicy
+	trace          TraceSink
+	traceRedaction TraceRedaction
+	headers        http.Header
+	secretHeaders  []string
+	auth           authenticator
+	transport      http.RoundTripper
+	wrappers       []func(http.RoundTripper) http.RoundTripper
+	rateLimiter    *RateLimiter
+	inFlight       *ConcurrencyLimiter
+	breakers       *CircuitBreakers
+
+	disallowUnknownFields bool
+	challengeMethod       ChallengeMethod
+	negotiatedMu          sync.Mutex
+	negotiated            map[string]ChallengeMethod
 }
 
-type debugTransport struct {
-	http.RoundTripper
+type ClientOption func(*Client)
+
+// WithRetryPolicy sets the retry policy for Ping and GetChallenge.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
 }
 
-func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	reqDump, _ := httputil.DumpRequestOut(req, true)
-	fmt.Printf("REQUEST:\n%s\n", reqDump)
-	
-	resp, err := t.RoundTripper.RoundTrip(req)
-	if err != nil {
-		return resp, err
+// WithSubmitRetryPolicy sets the retry policy for SubmitSolution. Whatever the
+// policy says, a submission is only repeated when the server cannot have
+// processed it.
+func WithSubmitRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.submitRetry = policy
+	}
+}
+
+// WithTransportWrapper wraps the client's transport, e.g. with a
+// RecordingTransport or by replacing it with a ReplayTransport. Wrappers are
+// applied in order around the base transport, see WithTransport.
+func WithTransportWrapper(wrap func(http.RoundTripper) http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.wrappers = append(c.wrappers, wrap)
 	}
-	
-	respDump, _ := httputil.DumpResponse(resp, true)
-	fmt.Printf("RESPONSE:\n%s\n", respDump)
-	
-	return resp, err
 }
 
-func NewClient(baseURL string) *Client {
-	transport := http.DefaultTransport
-	if os.Getenv("DEBUG_HTTP") != "" {
-		transport = &debugTransport{RoundTripper: transport}
+// WithTrace records every round trip to sink, overriding DEBUG_HTTP. The
+// sink is closed by Client.Close.
+func WithTrace(sink TraceSink, redaction TraceRedaction) ClientOption {
+	return func(c *Client) {
+		c.trace = sink
+		c.traceRedaction = redaction
 	}
+}
 
-	return &Client{
+// NewClient creates a client for baseURL. Unless WithTrace is given, the
+// DEBUG_HTTP and DEBUG_HTTP_REDACT environment variables select a trace sink,
+// see ParseTraceSink and ParseTraceRedaction.
+func NewClient(baseURL string, options ...ClientOption) *Client {
+	client := &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: transport,
+			Timeout: 30 * time.Second,
 		},
+		transport:   http.DefaultTransport,
+		retry:       DefaultRetryPolicy(),
+		submitRetry: DefaultSubmitRetryPolicy(),
+	}
+	for _, option := range options {
+		option(client)
+	}
+
+	transport := client.transport
+	for _, wrap := range client.wrappers {
+		transport = wrap(transport)
 	}
+
+	if client.trace == nil {
+		client.trace, client.traceRedaction = traceFromEnv()
+	}
+	if client.trace != nil {
+		if client.auth != nil {
+			client.traceRedaction = client.traceRedaction.withHeader(client.auth.secretHeader())
+		}
+		for _, header := range client.secretHeaders {
+			client.traceRedaction = client.traceRedaction.withHeader(header)
+		}
+		transport = &tracingTransport{
+			next:      transport,
+			sink:      client.trace,
+			redaction: client.traceRedaction,
+		}
+	}
+
+	client.httpClient.Transport = transport
+	return client
 }
 
-func (c *Client) Ping() error {
-	url := fmt.Sprintf("%s/ping", c.baseURL)
+func traceFromEnv() (TraceSink, TraceRedaction) {
+	redaction, err := ParseTraceRedaction(os.Getenv("DEBUG_HTTP_REDACT"))
+	if err != nil {
+		log.Printf("Ignoring DEBUG_HTTP_REDACT: %v", err)
+		redaction = DefaultTraceRedaction()
+	}
+	sink, err := ParseTraceSink(os.Getenv("DEBUG_HTTP"))
+	if err != nil {
+		log.Printf("HTTP tracing disabled: %v", err)
+		return nil, redaction
+	}
+	return sink, redaction
+}
+
+// Close releases idle connections and flushes the trace sink, if any.
+func (c *Client) Close() error {
+	c.httpClient.CloseIdleConnections()
+	if c.trace != nil {
+		return c.trace.Close()
+	}
+	return nil
+}
+
+type apiRequest struct {
+	endpoint   string
+	method     string
+	path       string
+	query      url.Values
+	body       []byte
+	idempotent bool
+	header     http.Header
+}
+
+type apiResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// send performs req with retries and returns the last response, whatever its
+// status. Transport errors are returned as *TransportError once the retry
+// budget is spent.
+func (c *Client) send(ctx context.Context, req apiRequest) (*apiResponse, error) {
+	req.endpoint = endpointName(req.method, req.path)
+
+	policy := c.retry
+	if !req.idempotent {
+		policy = c.submitRetry
+	}
+
+	// refreshed records that the one credential refresh has been spent;
+	// refreshAuth asks for fresh credentials on the attempt right after a 401
+	// only, so later backoff retries reuse the refreshed token.
+	refreshed, refreshAuth := false, false
+	for attempt := 1; ; attempt++ {
+		if err := c.breakers.allow(req.endpoint); err != nil {
+			return nil, err
+		}
+
+		resp, written, err := c.attempt(ctx, req, refreshAuth)
+		refreshAuth = false
+
+		var authErr *AuthError
+		if errors.As(err, &authErr) {
+			c.breakers.record(req.endpoint, outcomeIgnored)
+			return nil, err
+		}
+
+		status := 0
+		if err == nil {
+			status = resp.statusCode
+		}
+		c.breakers.record(req.endpoint, classifyAttempt(ctx, status, err))
+
+		// A rejected token gets one immediate extra attempt with fresh
+		// credentials; the server did not act on the request, so this is safe
+		// for submissions too.
+		if status == http.StatusUnauthorized && !refreshed && c.auth != nil && c.auth.refreshable() {
+			refreshed, refreshAuth = true, true
+			attempt--
+			continue
+		}
+		if err == nil && status < 300 || attempt >= policy.attempts() ||
+			!policy.retryDecision(status, written, req.idempotent) || ctx.Err() != nil {
+			if err != nil {
+				return nil, &TransportError{Endpoint: req.endpoint, Err: err}
+			}
+			return resp, nil
+		}
+
+		delay := policy.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.header.Get("Retry-After"), time.Now()); ok {
+				delay = policy.clampRetryAfter(retryAfter)
+			}
+		}
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			if err == nil {
+				err = fmt.Errorf("retry aborted after status %d: %w", status, sleepErr)
+			}
+			return nil, &TransportError{Endpoint: req.endpoint, Err: err}
+		}
+	}
+}
+
+// attempt performs a single round trip and reports whether the request was
+// fully written to the connection, which tells whether the server may have
+// seen it.
+func (c *Client) attempt(ctx context.Context, req apiRequest, refreshAuth bool) (*apiResponse, bool, error) {
+	written := false
+	trace := &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) { written = true },
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
 
-	resp, err := c.httpClient.Get(url)
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	httpReq, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), req.method, target, body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range c.headers {
+		httpReq.Header[key] = slices.Clone(values)
+	}
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	if req.body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != nil {
+		if err := c.auth.authenticate(ctx, httpReq, refreshAuth); err != nil {
+			return nil, false, &AuthError{Endpoint: req.endpoint, Err: err}
+		}
+	}
+
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+	if c.inFlight != nil {
+		if err := c.inFlight.Acquire(ctx); err != nil {
+			return nil, false, err
+		}
+		defer c.inFlight.Release()
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("failed to ping server: %w", err)
+		return nil, written, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("ping failed with status %d: %s", resp.StatusCode, string(body))
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	return nil
+	return &apiResponse{
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       respBody,
+	}, true, nil
+}
+
+func (c *Client) Ping() error {
+	return c.PingContext(context.Background())
+}
+
+func (c *Client) PingContext(ctx context.Context) error {
+	resp, err := c.send(ctx, apiRequest{method: http.MethodGet, path: "/ping", idempotent: true})
+	if err != nil {
+		return err
+	}
+
+	return checkStatus(endpointName(http.MethodGet, "/ping"), resp)
 }
 
 func (c *Client) GetChallenge(uuid, user string) (*ChallengeResponse, error) {
-	url := fmt.Sprintf("%s/challenge-me-easy", c.baseURL)
+	return c.GetChallengeContext(context.Background(), uuid, user)
+}
 
+func (c *Client) GetChallengeContext(ctx context.Context, uuid, user string) (*ChallengeResponse, error) {
 	request := ChallengeRequest{
 		UUID: uuid,
 		User: user,
 	}
 
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	var response ChallengeResponse
+	if err := c.FetchChallengeContext(ctx, EasyChallengePath, request, &response); err != nil {
+		return nil, err
 	}
+	return &response, nil
+}
 
-	req, err := http.NewRequest("GET", url, bytes.NewBuffer(jsonData))
+// FetchChallengeContext requests a challenge from path, sending request in
+// the form selected by WithChallengeMethod and decoding the answer into
+// response, which must be a pointer. A response implementing ChallengeDecoder
+// validates itself against the uuid of request; its objections are returned
+// as *ValidationError.
+func (c *Client) FetchChallengeContext(ctx context.Context, path string, request, response any) error {
+	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send challenge request: %w", err)
-	}
-	defer resp.Body.Close()
+	var resp *apiResponse
+	var endpoint string
+	methods := c.challengeMethods(path)
+	for i, method := range methods {
+		// Requesting a challenge for the same UUID yields the same challenge,
+		// so it is safe to retry, whatever the HTTP method.
+		req, err := challengeRequest(method, path, jsonData)
+		if err != nil {
+			return err
+		}
+		endpoint = endpointName(req.method, path)
+		if resp, err = c.send(ctx, req); err != nil {
+			return err
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("challenge request failed with status %d: %s", resp.StatusCode, string(body))
+		if resp.statusCode == http.StatusOK {
+			c.rememberChallengeMethod(path, method)
+			break
+		}
+		if i == len(methods)-1 || !slices.Contains(fallbackStatus, resp.statusCode) {
+			return checkStatus(endpoint, resp)
+		}
 	}
 
-	var response ChallengeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode challenge response: %w", err)
+	decoder, ok := response.(ChallengeDecoder)
+	if !ok {
+		if err := json.Unmarshal(resp.body, response); err != nil {
+			return &DecodeError{Endpoint: endpoint, Body: string(resp.body), Err: err}
+		}
+		return nil
 	}
 
-	return &response, nil
+	var requested struct {
+		UUID string `json:"uuid"`
+	}
+	json.Unmarshal(jsonData, &requested)
+	err = decoder.DecodeChallenge(resp.body, DecodeOptions{
+		UUID:                  requested.UUID,
+		DisallowUnknownFields: c.disallowUnknownFields,
+	})
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		validationErr.Endpoint, validationErr.Body = endpoint, string(resp.body)
+		return validationErr
+	}
+	if err != nil {
+		return &DecodeError{Endpoint: endpoint, Body: string(resp.body), Err: err}
+	}
+	return nil
 }
 
-func (c *Client) SubmitSolution(uuid, result, hash string) error {
-	url := fmt.Sprintf("%s/challenge-me-easy", c.baseURL)
+func (c *Client) SubmitSolution(uuid, result, hash string) (*SubmissionResult, error) {
+	return c.SubmitSolutionContext(context.Background(), uuid, result, hash)
+}
 
+// SubmitSolutionContext submits an answer. An HTTP 200 only means the
+// submission was received; the returned result carries the server's verdict.
+func (c *Client) SubmitSolutionContext(ctx context.Context, uuid, result, hash string) (*SubmissionResult, error) {
 	request := SolutionRequest{
 		UUID:   uuid,
 		Result: result,
 		Hash:   hash,
 	}
 
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return fmt.Errorf("failed to marshal solution: %w", err)
-	}
+	return c.SubmitContext(ctx, EasyChallengePath, uuid, request)
+}
 
-	resp, err := c.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+// SubmitContext posts the JSON answer for challenge uuid to path.
+func (c *Client) SubmitContext(ctx context.Context, path, uuid string, answer any) (*SubmissionResult, error) {
+	jsonData, err := json.Marshal(answer)
 	if err != nil {
-		return fmt.Errorf("failed to send solution: %w", err)
+		return nil, fmt.Errorf("failed to marshal solution: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	resp, err := c.send(ctx, apiRequest{
+		method: http.MethodPost,
+		path:   path,
+		body:   jsonData,
+		header: http.Header{"Idempotency-Key": {uuid}},
+	})
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("solution submission failed with status %d: %s", resp.StatusCode, string(body))
+	if err := checkStatus(endpointName(http.MethodPost, path), resp); err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Solution submitted successfully. Response: %s\n", string(body))
-	return nil
+	return parseSubmissionResult(resp.body), nil
 }
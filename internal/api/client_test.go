@@ -1,10 +1,13 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -147,10 +150,14 @@ func TestSubmitSolutionSuccess(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL)
-	err := client.SubmitSolution("test-uuid", "0,1,2,4,6,8,9,10", "test-hash")
+	result, err := client.SubmitSolution("test-uuid", "0,1,2,4,6,8,9,10", "test-hash")
 
 	if err != nil {
-		t.Errorf("SubmitSolution should succeed, got error: %v", err)
+		t.Fatalf("SubmitSolution should succeed, got error: %v", err)
+	}
+	// Plain text carries no explicit verdict.
+	if result.Verdict != VerdictUnknown || result.Message != "Solution accepted" || string(result.Raw) != "Solution accepted" {
+		t.Errorf("Expected unknown verdict with message and raw body, got %+v", result)
 	}
 }
 
@@ -162,9 +169,38 @@ func TestSubmitSolutionFailure(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL)
-	err := client.SubmitSolution("test-uuid", "wrong-result", "wrong-hash")
+	_, err := client.SubmitSolution("test-uuid", "wrong-result", "wrong-hash")
 
 	if err == nil {
 		t.Error("SubmitSolution should fail with bad request")
 	}
 }
+
+func TestContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.PingContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded from PingContext, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GetChallengeContext(ctx, "test-uuid", "test-user"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancellation from GetChallengeContext, got %v", err)
+	}
+	if _, err := client.SubmitSolutionContext(ctx, "test-uuid", "0", "hash"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancellation from SubmitSolutionContext, got %v", err)
+	}
+}
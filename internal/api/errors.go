@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StatusError is returned when the server answers with an unexpected HTTP
+// status.
+type StatusError struct {
+	Endpoint   string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s failed with status %d: %s", e.Endpoint, e.StatusCode, e.Body)
+}
+
+// ClientError reports a 4xx status, i.e. the server rejected the request.
+func (e *StatusError) ClientError() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500
+}
+
+// ServerError reports a 5xx status.
+func (e *StatusError) ServerError() bool {
+	return e.StatusCode >= 500
+}
+
+// TransportError is returned when no HTTP response was received, e.g. on
+// connection failures, timeouts or cancellation.
+type TransportError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("%s request failed: %v", e.Endpoint, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned when a response body cannot be decoded.
+type DecodeError struct {
+	Endpoint string
+	Body     string
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode %s response: %v", e.Endpoint, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func endpointName(method, path string) string {
+	return method + " " + path
+}
+
+func checkStatus(endpoint string, resp *apiResponse) error {
+	if resp.statusCode != http.StatusOK {
+		return &StatusError{Endpoint: endpoint, StatusCode: resp.statusCode, Body: string(resp.body)}
+	}
+	return nil
+}
+
+// AuthError is returned when the credentials for a request cannot be
+// obtained, e.g. a missing token file. Such requests are not retried.
+type AuthError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s: failed to get credentials: %v", e.Endpoint, e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Invalid solution"))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).SubmitSolution("test-uuid", "0", "hash")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Expected *StatusError, got %T: %v", err, err)
+	}
+	if statusErr.Endpoint != "POST /challenge-me-easy" || statusErr.StatusCode != http.StatusBadRequest || statusErr.Body != "Invalid solution" {
+		t.Errorf("Unexpected status error: %+v", statusErr)
+	}
+	if !statusErr.ClientError() || statusErr.ServerError() {
+		t.Errorf("Status 400 should be a client error")
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("not json"))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).GetChallenge("test-uuid", "test-user")
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Expected *DecodeError, got %T: %v", err, err)
+	}
+	if decodeErr.Body != "not json" {
+		t.Errorf("Expected body to be kept, got %q", decodeErr.Body)
+	}
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("DecodeError should wrap the JSON error, got %v", decodeErr.Err)
+	}
+}
+
+func TestTransportError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	err = NewClient("http://"+addr, WithRetryPolicy(NoRetry())).Ping()
+
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) {
+		t.Fatalf("Expected *TransportError, got %T: %v", err, err)
+	}
+	if transportErr.Endpoint != "GET /ping" {
+		t.Errorf("Expected endpoint GET /ping, got %q", transportErr.Endpoint)
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		t.Error("Transport failure should not be a StatusError")
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ChallengeMethod selects how a challenge request reaches the server.
+type ChallengeMethod string
+
+const (
+	// ChallengeMethodAuto tries ChallengeFallbackOrder until a method is
+	// accepted and keeps using that one for the path.
+	ChallengeMethodAuto ChallengeMethod = "auto"
+	// ChallengeMethodPost sends the request as a JSON POST body.
+	ChallengeMethodPost ChallengeMethod = "post"
+	// ChallengeMethodQuery sends a GET with the request fields as URL
+	// parameters.
+	ChallengeMethodQuery ChallengeMethod = "query"
+	// ChallengeMethodGetBody sends a GET with a JSON body, which the original
+	// API accepts but many proxies drop.
+	ChallengeMethodGetBody ChallengeMethod = "get-body"
+)
+
+// ChallengeFallbackOrder is the order in which ChallengeMethodAuto tries the
+// methods.
+var ChallengeFallbackOrder = []ChallengeMethod{ChallengeMethodPost, ChallengeMethodQuery, ChallengeMethodGetBody}
+
+// fallbackStatus are the answers with which a server says it does not
+// understand the request in the form it was sent, so that auto mode moves on
+// to the next method.
+var fallbackStatus = []int{
+	http.StatusBadRequest,
+	http.StatusNotFound,
+	http.StatusMethodNotAllowed,
+	http.StatusLengthRequired,
+	http.StatusUnsupportedMediaType,
+	http.StatusUnprocessableEntity,
+	http.StatusNotImplemented,
+}
+
+// ParseChallengeMethod accepts auto, post, query and get-body; empty means
+// auto.
+func ParseChallengeMethod(value string) (ChallengeMethod, error) {
+	if value == "" {
+		return ChallengeMethodAuto, nil
+	}
+	method := ChallengeMethod(value)
+	if method != ChallengeMethodAuto && !slices.Contains(ChallengeFallbackOrder, method) {
+		return "", fmt.Errorf("unknown challenge method %q, expected auto, post, query or get-body", value)
+	}
+	return method, nil
+}
+
+// WithChallengeMethod selects how challenges are requested; the default is
+// ChallengeMethodAuto.
+func WithChallengeMethod(method ChallengeMethod) ClientOption {
+	return func(c *Client) {
+		c.challengeMethod = method
+	}
+}
+
+// challengeMethods returns the methods to try for path, in order.
+func (c *Client) challengeMethods(path string) []ChallengeMethod {
+	if c.challengeMethod != "" && c.challengeMethod != ChallengeMethodAuto {
+		return []ChallengeMethod{c.challengeMethod}
+	}
+
+	c.negotiatedMu.Lock()
+	defer c.negotiatedMu.Unlock()
+	if method, ok := c.negotiated[path]; ok {
+		return []ChallengeMethod{method}
+	}
+	return ChallengeFallbackOrder
+}
+
+func (c *Client) rememberChallengeMethod(path string, method ChallengeMethod) {
+	if c.challengeMethod != "" && c.challengeMethod != ChallengeMethodAuto {
+		return
+	}
+
+	c.negotiatedMu.Lock()
+	defer c.negotiatedMu.Unlock()
+	if c.negotiated == nil {
+		c.negotiated = make(map[string]ChallengeMethod)
+	}
+	c.negotiated[path] = method
+}
+
+// challengeRequest builds the request for path in the given form from the
+// JSON encoded challenge request.
+func challengeRequest(method ChallengeMethod, path string, body []byte) (apiRequest, error) {
+	switch method {
+	case ChallengeMethodPost:
+		return apiRequest{method: http.MethodPost, path: path, body: body, idempotent: true}, nil
+	case ChallengeMethodGetBody:
+		return apiRequest{method: http.MethodGet, path: path, body: body, idempotent: true}, nil
+	case ChallengeMethodQuery:
+		query, err := queryValues(body)
+		if err != nil {
+			return apiRequest{}, err
+		}
+		return apiRequest{method: http.MethodGet, path: path, query: query, idempotent: true}, nil
+	}
+	return apiRequest{}, fmt.Errorf("unknown challenge method %q", method)
+}
+
+// queryValues turns the top-level fields of a JSON object into URL
+// parameters. Strings are sent as is, other values as their JSON text.
+func queryValues(body []byte) (url.Values, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("challenge request must be a JSON object to be sent as query parameters: %w", err)
+	}
+
+	query := make(url.Values, len(fields))
+	for name, raw := range fields {
+		var text string
+		if json.Unmarshal(raw, &text) != nil {
+			text = strings.TrimSpace(string(raw))
+		}
+		query.Set(name, text)
+	}
+	return query, nil
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newFormServer serves challenges requested in the given forms and answers
+// 405 to any other, recording the form of every request it sees.
+func newFormServer(t *testing.T, accepted ...ChallengeMethod) (*httptest.Server, *[]ChallengeMethod) {
+	t.Helper()
+	var seen []ChallengeMethod
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var request ChallengeRequest
+		form := ChallengeMethodPost
+		switch {
+		case r.Method == http.MethodGet && len(body) == 0:
+			form = ChallengeMethodQuery
+			request.UUID, request.User = r.URL.Query().Get("uuid"), r.URL.Query().Get("user")
+		case r.Method == http.MethodGet:
+			form = ChallengeMethodGetBody
+		}
+		seen = append(seen, form)
+		if form != ChallengeMethodQuery {
+			json.Unmarshal(body, &request)
+		}
+
+		if !slices.Contains(accepted, form) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if request.UUID != "test-uuid" || request.User != "test user" {
+			t.Errorf("Unexpected %s request values: %+v", form, request)
+		}
+		json.NewEncoder(w).Encode(ChallengeResponse{UUID: request.UUID, SetX: "4", SetY: "4", SetZ: "5"})
+	}))
+	return server, &seen
+}
+
+func TestChallengeMethods(t *testing.T) {
+	for _, method := range ChallengeFallbackOrder {
+		t.Run(string(method), func(t *testing.T) {
+			server, seen := newFormServer(t, method)
+			defer server.Close()
+
+			client := NewClient(server.URL, WithChallengeMethod(method))
+			if _, err := client.GetChallenge("test-uuid", "test user"); err != nil {
+				t.Fatalf("GetChallenge failed: %v", err)
+			}
+			if len(*seen) != 1 || (*seen)[0] != method {
+				t.Errorf("Expected a single %s request, got %v", method, *seen)
+			}
+		})
+	}
+}
+
+func TestChallengeMethodAutoFallback(t *testing.T) {
+	server, seen := newFormServer(t, ChallengeMethodGetBody)
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	if _, err := client.GetChallenge("test-uuid", "test user"); err != nil {
+		t.Fatalf("GetChallenge should fall back to get-body, got %v", err)
+	}
+	expected := []ChallengeMethod{ChallengeMethodPost, ChallengeMethodQuery, ChallengeMethodGetBody}
+	if !slices.Equal(*seen, expected) {
+		t.Errorf("Expected attempts %v, got %v", expected, *seen)
+	}
+
+	// The negotiated method is used straight away from now on.
+	*seen = nil
+	if _, err := client.GetChallenge("test-uuid", "test user"); err != nil {
+		t.Fatalf("Second GetChallenge failed: %v", err)
+	}
+	if len(*seen) != 1 || (*seen)[0] != ChallengeMethodGetBody {
+		t.Errorf("Expected only a get-body request, got %v", *seen)
+	}
+}
+
+func TestChallengeMethodAutoGivesUp(t *testing.T) {
+	server, seen := newFormServer(t)
+	defer server.Close()
+
+	_, err := NewClient(server.URL).GetChallenge("test-uuid", "test user")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusMethodNotAllowed || statusErr.Endpoint != "GET /challenge-me-easy" {
+		t.Errorf("Expected the last method's 405, got %v", err)
+	}
+	if len(*seen) != 3 {
+		t.Errorf("Expected every method to be tried, got %v", *seen)
+	}
+}
+
+func TestChallengeMethodNoFallbackOnServerError(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL).GetChallenge("test-uuid", "user"); err == nil {
+		t.Fatal("GetChallenge should fail")
+	}
+	if calls != 1 {
+		t.Errorf("A 403 is not about the request form and should not fall back, got %d requests", calls)
+	}
+}
+
+func TestQueryModeRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.json")
+	server, _ := newFormServer(t, ChallengeMethodQuery)
+	defer server.Close()
+
+	recorder := NewClient(server.URL, WithChallengeMethod(ChallengeMethodQuery), WithTransportWrapper(func(next http.RoundTripper) http.RoundTripper {
+		return NewRecordingTransport(next, path, DefaultRedaction())
+	}))
+	if _, err := recorder.GetChallenge("test-uuid", "test user"); err != nil {
+		t.Fatalf("GetChallenge failed while recording: %v", err)
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("Cassette not written: %v", err)
+	}
+	if recorded := cassette.Interactions[0].Request.Path; strings.Contains(recorded, "test+user") || !strings.Contains(recorded, "user=%3CUSER%3E") {
+		t.Errorf("User should be redacted from the query, got %s", recorded)
+	}
+
+	replay, err := NewReplayTransport(path, DefaultRedaction())
+	if err != nil {
+		t.Fatalf("Failed to load cassette: %v", err)
+	}
+	replayer := NewClient("http://cassette.invalid", WithChallengeMethod(ChallengeMethodQuery),
+		WithTransportWrapper(func(http.RoundTripper) http.RoundTripper { return replay }))
+	if _, err := replayer.GetChallenge("test-uuid", "bob"); err != nil {
+		t.Errorf("Replay with another user should match the redacted query, got %v", err)
+	}
+}
+
+func TestParseChallengeMethod(t *testing.T) {
+	for _, value := range []string{"", "auto", "post", "query", "get-body"} {
+		if _, err := ParseChallengeMethod(value); err != nil {
+			t.Errorf("ParseChallengeMethod(%q) failed: %v", value, err)
+		}
+	}
+	if _, err := ParseChallengeMethod("put"); err == nil {
+		t.Error("Expected an error for an unknown method")
+	}
+}
+
+func TestQueryValues(t *testing.T) {
+	query, err := queryValues([]byte(`{"uuid":"u1","user":"a b","radius":2,"strict":true}`))
+	if err != nil {
+		t.Fatalf("queryValues failed: %v", err)
+	}
+	if encoded := query.Encode(); encoded != "radius=2&strict=true&user=a+b&uuid=u1" {
+		t.Errorf("Unexpected query %s", encoded)
+	}
+	if _, err := queryValues([]byte(`["u1"]`)); err == nil {
+		t.Error("Expected an error for a non-object request")
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LimiterStats reports how much a limiter has slowed requests down.
+type LimiterStats struct {
+	// Requests is the number of requests that passed the limiter.
+	Requests int64
+	// Delayed counts the requests that had to wait.
+	Delayed   int64
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+func (s LimiterStats) String() string {
+	return fmt.Sprintf("%d requests, %d delayed, waited %v in total, %v at most",
+		s.Requests, s.Delayed, s.TotalWait, s.MaxWait)
+}
+
+type limiterStats struct {
+	mu    sync.Mutex
+	stats LimiterStats
+}
+
+// observe counts a request that passed the limiter; delayed requests are the
+// ones that had to block, for wait.
+func (s *limiterStats) observe(delayed bool, wait time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Requests++
+	if delayed {
+		s.stats.Delayed++
+		s.stats.TotalWait += wait
+		s.stats.MaxWait = max(s.stats.MaxWait, wait)
+	}
+}
+
+func (s *limiterStats) snapshot() LimiterStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// RateLimiter is a token bucket. Pass the same limiter to several clients to
+// share one budget between them; it is safe for concurrent use.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	stats  limiterStats
+}
+
+// NewRateLimiter allows rate requests per second on average and bursts of up
+// to burst requests (at least 1).
+func NewRateLimiter(rate float64, burst int) (*RateLimiter, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("rate must be positive, got %v", rate)
+	}
+	burst = max(burst, 1)
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}, nil
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// Reserve a token now, going into debt if necessary, so that waiters are
+	// served in arrival order.
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	start := time.Now()
+	if err := sleepContext(ctx, wait); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	if wait > 0 {
+		l.stats.observe(true, time.Since(start))
+	} else {
+		l.stats.observe(false, 0)
+	}
+	return nil
+}
+
+func (l *RateLimiter) Stats() LimiterStats {
+	return l.stats.snapshot()
+}
+
+// ConcurrencyLimiter caps the number of requests in flight. Like RateLimiter
+// it may be shared between clients.
+type ConcurrencyLimiter struct {
+	slots chan struct{}
+	stats limiterStats
+}
+
+func NewConcurrencyLimiter(maxInFlight int) (*ConcurrencyLimiter, error) {
+	if maxInFlight < 1 {
+		return nil, fmt.Errorf("max in flight must be at least 1, got %d", maxInFlight)
+	}
+	return &ConcurrencyLimiter{slots: make(chan struct{}, maxInFlight)}, nil
+}
+
+// Acquire blocks until a slot is free or ctx is done. Every successful
+// Acquire must be followed by Release.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		l.stats.observe(false, 0)
+		return nil
+	default:
+	}
+
+	start := time.Now()
+	select {
+	case l.slots <- struct{}{}:
+		l.stats.observe(true, time.Since(start))
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *ConcurrencyLimiter) Release() {
+	<-l.slots
+}
+
+// InFlight returns the number of requests currently holding a slot.
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.slots)
+}
+
+func (l *ConcurrencyLimiter) Stats() LimiterStats {
+	return l.stats.snapshot()
+}
+
+// WithRateLimiter makes every attempt, retries included, take a token from
+// limiter first.
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return func(c *Client) {
+		c.rateLimiter = limiter
+	}
+}
+
+// WithMaxInFlight holds a slot of limiter for the duration of every attempt.
+// Backoff between retries does not hold a slot.
+func WithMaxInFlight(limiter *ConcurrencyLimiter) ClientOption {
+	return func(c *Client) {
+		c.inFlight = limiter
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurstThenRate(t *testing.T) {
+	limiter, err := NewRateLimiter(50, 2)
+	if err != nil {
+		t.Fatalf("NewRateLimiter failed: %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+	}
+	// The burst covers two requests, the remaining four need 20ms each.
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("Expected about 80ms of throttling, took %v", elapsed)
+	}
+
+	stats := limiter.Stats()
+	if stats.Requests != 6 || stats.Delayed != 4 || stats.TotalWait <= 0 || stats.MaxWait > stats.TotalWait {
+		t.Errorf("Expected 4 of 6 requests delayed, got %+v", stats)
+	}
+}
+
+func TestLimitersIdle(t *testing.T) {
+	rateLimiter, _ := NewRateLimiter(1, 100)
+	concurrencyLimiter, _ := NewConcurrencyLimiter(1)
+	for i := 0; i < 5; i++ {
+		if err := rateLimiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+		if err := concurrencyLimiter.Acquire(context.Background()); err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		concurrencyLimiter.Release()
+	}
+
+	for name, stats := range map[string]LimiterStats{"rate": rateLimiter.Stats(), "concurrency": concurrencyLimiter.Stats()} {
+		if stats != (LimiterStats{Requests: 5}) {
+			t.Errorf("%s limiter: expected 5 undelayed requests, got %+v", name, stats)
+		}
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	limiter, _ := NewRateLimiter(1, 1)
+	limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if stats := limiter.Stats(); stats.Requests != 1 {
+		t.Errorf("Cancelled wait should not count as a request, got %+v", stats)
+	}
+}
+
+func TestRateLimiterSharedBetweenClients(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	limiter, _ := NewRateLimiter(100, 1)
+	clients := []*Client{
+		NewClient(server.URL, WithRateLimiter(limiter)),
+		NewClient(server.URL, WithRateLimiter(limiter)),
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(client *Client) {
+			defer wg.Done()
+			if err := client.Ping(); err != nil {
+				t.Errorf("Ping failed: %v", err)
+			}
+		}(clients[i%2])
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("10 requests at 100/s with burst 1 should take about 90ms, took %v", elapsed)
+	}
+	if requests.Load() != 10 || limiter.Stats().Requests != 10 {
+		t.Errorf("Expected 10 requests, server saw %d, limiter %d", requests.Load(), limiter.Stats().Requests)
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	var current, peak atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		defer current.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		<-release
+	}))
+	defer server.Close()
+
+	limiter, err := NewConcurrencyLimiter(2)
+	if err != nil {
+		t.Fatalf("NewConcurrencyLimiter failed: %v", err)
+	}
+	client := NewClient(server.URL, WithMaxInFlight(limiter))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Ping(); err != nil {
+				t.Errorf("Ping failed: %v", err)
+			}
+		}()
+	}
+	// Hold the first two requests until the other six queue up behind them.
+	for peak.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Errorf("Expected at most 2 requests in flight, saw %d", peak.Load())
+	}
+	stats := limiter.Stats()
+	if stats.Requests != 8 || stats.Delayed != 6 || stats.TotalWait <= 0 {
+		t.Errorf("Expected 6 of 8 requests delayed, got %+v", stats)
+	}
+	if limiter.InFlight() != 0 {
+		t.Errorf("All slots should be released, %d still held", limiter.InFlight())
+	}
+}
+
+func TestLimiterConstructorsValidate(t *testing.T) {
+	if _, err := NewRateLimiter(0, 1); err == nil {
+		t.Error("Zero rate should be rejected")
+	}
+	if _, err := NewConcurrencyLimiter(0); err == nil {
+		t.Error("Zero in-flight cap should be rejected")
+	}
+}
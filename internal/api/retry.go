@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how often and how patiently a request is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts; values below 2 disable
+	// retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt; it grows by
+	// Multiplier per attempt up to MaxBackoff. MaxBackoff also caps the delay
+	// a server may request with Retry-After.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction (0..1) of each delay that is randomized.
+	Jitter float64
+	// RetryableStatus lists the HTTP status codes worth retrying.
+	RetryableStatus []int
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     3,
+		InitialBackoff:  200 * time.Millisecond,
+		MaxBackoff:      5 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.5,
+		RetryableStatus: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// DefaultSubmitRetryPolicy is used for solution submissions. Submissions are
+// not idempotent, so besides the attempt budget the client only retries when
+// the server cannot have processed the request, see retryDecision.
+func DefaultSubmitRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.RetryableStatus = []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}
+	return policy
+}
+
+func NoRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// unprocessedStatus are the codes with which a server states that it did not
+// act on the request, which makes them safe to retry even for submissions.
+var unprocessedStatus = []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}
+
+// retryDecision reports whether an attempt that ended with status (0 for a
+// transport error) may be repeated. Non-idempotent requests are only retried
+// when the request never reached the server or the server explicitly refused
+// to process it.
+func (p RetryPolicy) retryDecision(status int, requestWritten, idempotent bool) bool {
+	if status == 0 {
+		return idempotent || !requestWritten
+	}
+	if !slices.Contains(p.RetryableStatus, status) {
+		return false
+	}
+	return idempotent || slices.Contains(unprocessedStatus, status)
+}
+
+func (p RetryPolicy) attempts() int {
+	return max(p.MaxAttempts, 1)
+}
+
+// backoff returns the delay before attempt number attempt+1 (attempt starts at 1).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	jitter := min(max(p.Jitter, 0), 1)
+	delay = delay*(1-jitter) + rand.Float64()*delay*jitter
+	return time.Duration(delay)
+}
+
+// clampRetryAfter keeps a server-requested delay within MaxBackoff, so that a
+// misbehaving server cannot park the client for hours.
+func (p RetryPolicy) clampRetryAfter(delay time.Duration) time.Duration {
+	if p.MaxBackoff > 0 {
+		return min(delay, p.MaxBackoff)
+	}
+	return delay
+}
+
+// parseRetryAfter understands both forms of the Retry-After header: delay in
+// seconds and an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
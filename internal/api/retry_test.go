@@ -0,0 +1,276 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fastRetryPolicy(attempts int) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = attempts
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+func TestPingRetriesRetryableStatus(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithRetryPolicy(fastRetryPolicy(3)))
+	if err := client.Ping(); err != nil {
+		t.Errorf("Ping should succeed after retries, got error: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithRetryPolicy(fastRetryPolicy(4)))
+	if _, err := client.GetChallenge("uuid", "user"); err == nil {
+		t.Error("GetChallenge should fail once retries are exhausted")
+	}
+	if calls.Load() != 4 {
+		t.Errorf("Expected 4 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetrySkipsNonRetryableStatus(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithRetryPolicy(fastRetryPolicy(5)))
+	if err := client.Ping(); err == nil {
+		t.Error("Ping should fail")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var first time.Time
+	var elapsed time.Duration
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		elapsed = time.Since(first)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	policy := fastRetryPolicy(2)
+	policy.MaxBackoff = 2 * time.Second
+	client := NewClient(server.URL, WithRetryPolicy(policy))
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping should succeed, got error: %v", err)
+	}
+	if elapsed < 900*time.Millisecond {
+		t.Errorf("Expected to wait about 1s as requested by Retry-After, waited %v", elapsed)
+	}
+}
+
+func TestRetryAfterCappedByMaxBackoff(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "7200")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	policy := fastRetryPolicy(2)
+	policy.MaxBackoff = 20 * time.Millisecond
+	client := NewClient(server.URL, WithRetryPolicy(policy))
+
+	start := time.Now()
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping should succeed after the capped wait, got error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Retry-After of two hours should be capped at MaxBackoff, waited %v", elapsed)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetryStopsOnContextCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	policy := fastRetryPolicy(3)
+	policy.MaxBackoff = time.Minute
+	client := NewClient(server.URL, WithRetryPolicy(policy))
+	if err := client.PingContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestSubmitSolutionRetryIsIdempotencyAware(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		expectedCalls int32
+	}{
+		{"server overloaded before processing", http.StatusServiceUnavailable, 3},
+		{"rate limited", http.StatusTooManyRequests, 3},
+		{"gateway error may have processed the request", http.StatusBadGateway, 1},
+		{"gateway timeout may have processed the request", http.StatusGatewayTimeout, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if r.Header.Get("Idempotency-Key") != "test-uuid" {
+					t.Errorf("Expected Idempotency-Key test-uuid, got %q", r.Header.Get("Idempotency-Key"))
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			// The policy lists 502 and 504 too; the idempotency guard must still
+			// refuse to resubmit on them.
+			client := NewClient(server.URL, WithSubmitRetryPolicy(fastRetryPolicy(3)))
+
+			if _, err := client.SubmitSolution("test-uuid", "0", "hash"); err == nil {
+				t.Error("SubmitSolution should fail")
+			}
+			if calls.Load() != tt.expectedCalls {
+				t.Errorf("Expected %d attempts, got %d", tt.expectedCalls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestSubmitSolutionRetriesUnsentRequest(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	client := NewClient("http://"+addr, WithSubmitRetryPolicy(fastRetryPolicy(2)))
+
+	start := time.Now()
+	if _, err := client.SubmitSolution("uuid", "0", "hash"); err == nil {
+		t.Fatal("SubmitSolution should fail against a closed port")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Connection refused should fail fast")
+	}
+}
+
+func TestRetryDecision(t *testing.T) {
+	policy := DefaultRetryPolicy()
+
+	tests := []struct {
+		name       string
+		status     int
+		written    bool
+		idempotent bool
+		expected   bool
+	}{
+		{"idempotent transport error", 0, true, true, true},
+		{"unsent submission", 0, false, false, true},
+		{"sent submission lost", 0, true, false, false},
+		{"idempotent 504", http.StatusGatewayTimeout, true, true, true},
+		{"submission 504", http.StatusGatewayTimeout, true, false, false},
+		{"submission 503", http.StatusServiceUnavailable, true, false, true},
+		{"not retryable 500", http.StatusInternalServerError, true, true, false},
+	}
+
+	for _, tt := range tests {
+		if actual := policy.retryDecision(tt.status, tt.written, tt.idempotent); actual != tt.expected {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.expected, actual)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("Attempt %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(1); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("Jittered backoff %v outside [50ms, 100ms]", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{"Mon, 01 Jan 2024 12:00:10 GMT", 10 * time.Second, true},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		actual, ok := parseRetryAfter(tt.value, now)
+		if actual != tt.expected || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %t; expected %v, %t", tt.value, actual, ok, tt.expected, tt.ok)
+		}
+	}
+}
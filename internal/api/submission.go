@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Verdict string
+
+const (
+	VerdictAccepted Verdict = "accepted"
+	VerdictRejected Verdict = "rejected"
+	// VerdictUnknown means the response did not say whether the answer was
+	// correct.
+	VerdictUnknown Verdict = "unknown"
+)
+
+// SubmissionResult is the server's answer to a solution submission. The
+// response format is not fixed, so JSON objects and plain text are both
+// understood; Fields and Raw keep everything the server sent. The verdict is
+// only taken from explicit JSON fields: free text such as "Solution not
+// accepted" is too easy to misread and yields VerdictUnknown.
+type SubmissionResult struct {
+	Verdict Verdict
+	Message string
+	// Score is nil when the server did not report one.
+	Score *float64
+	// Fields holds the decoded top-level JSON object, if the body was one.
+	Fields map[string]any
+	Raw    []byte
+}
+
+func (r *SubmissionResult) Accepted() bool {
+	return r.Verdict == VerdictAccepted
+}
+
+func (r *SubmissionResult) String() string {
+	if r.Message != "" {
+		return string(r.Verdict) + ": " + r.Message
+	}
+	return string(r.Verdict) + ": " + string(bytes.TrimSpace(r.Raw))
+}
+
+var (
+	// flagKeys hold a boolean verdict, statusKeys a status word.
+	flagKeys    = []string{"accepted", "correct", "success", "valid"}
+	statusKeys  = []string{"verdict", "status", "result"}
+	errorKeys   = []string{"error", "errors"}
+	messageKeys = []string{"message", "msg", "detail", "error"}
+	scoreKeys   = []string{"score", "points"}
+
+	// Status values are compared as a whole, case-insensitively.
+	rejectedStatus = []string{"rejected", "incorrect", "wrong", "invalid", "fail", "failed", "failure", "error", "denied", "false"}
+	acceptedStatus = []string{"accepted", "correct", "success", "succeeded", "pass", "passed", "ok", "valid", "true"}
+)
+
+func parseSubmissionResult(body []byte) *SubmissionResult {
+	result := &SubmissionResult{Verdict: VerdictUnknown, Raw: body}
+
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		result.Message = strings.TrimSpace(string(body))
+		return result
+	}
+	result.Fields = fields
+
+	for _, key := range flagKeys {
+		if accepted, ok := lookup(fields, key).(bool); ok {
+			result.Verdict = verdictFromBool(accepted)
+			break
+		}
+	}
+	if result.Verdict == VerdictUnknown {
+		for _, key := range statusKeys {
+			if verdict := verdictFromStatus(lookup(fields, key)); verdict != VerdictUnknown {
+				result.Verdict = verdict
+				break
+			}
+		}
+	}
+	// Whatever else it says, a response reporting an error did not accept
+	// the answer.
+	if slices.ContainsFunc(errorKeys, func(key string) bool { return present(lookup(fields, key)) }) {
+		result.Verdict = VerdictRejected
+	}
+
+	for _, key := range messageKeys {
+		if message, ok := lookup(fields, key).(string); ok {
+			result.Message = message
+			break
+		}
+	}
+	for _, key := range scoreKeys {
+		if score, ok := numberValue(lookup(fields, key)); ok {
+			result.Score = &score
+			break
+		}
+	}
+
+	return result
+}
+
+// lookup finds key case-insensitively.
+func lookup(fields map[string]any, key string) any {
+	if value, ok := fields[key]; ok {
+		return value
+	}
+	for k, value := range fields {
+		if strings.EqualFold(k, key) {
+			return value
+		}
+	}
+	return nil
+}
+
+func verdictFromBool(accepted bool) Verdict {
+	if accepted {
+		return VerdictAccepted
+	}
+	return VerdictRejected
+}
+
+// verdictFromStatus maps a boolean or a single status word such as "OK" or
+// "rejected"; anything longer is not a status and yields VerdictUnknown.
+func verdictFromStatus(value any) Verdict {
+	switch v := value.(type) {
+	case bool:
+		return verdictFromBool(v)
+	case string:
+		status := strings.ToLower(strings.TrimSpace(v))
+		switch {
+		case slices.Contains(rejectedStatus, status):
+			return VerdictRejected
+		case slices.Contains(acceptedStatus, status):
+			return VerdictAccepted
+		}
+	}
+	return VerdictUnknown
+}
+
+// present reports whether an error field carries anything: null, false, ""
+// and empty collections do not count.
+func present(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return strings.TrimSpace(v) != ""
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	}
+	return true
+}
+
+func numberValue(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		score, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return score, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,64 @@
+package api
+
+import "testing"
+
+func TestParseSubmissionResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		verdict Verdict
+		message string
+	}{
+		{"plain text is not a verdict", "Solution accepted", VerdictUnknown, "Solution accepted"},
+		{"plain text negation", "Solution not accepted", VerdictUnknown, "Solution not accepted"},
+		{"plain text incorrect", "Incorrect hash\n", VerdictUnknown, "Incorrect hash"},
+		{"plain text unclear", "received", VerdictUnknown, "received"},
+		{"JSON bool", `{"accepted": false, "message": "wrong hash"}`, VerdictRejected, "wrong hash"},
+		{"JSON bool accepted", `{"correct": true}`, VerdictAccepted, ""},
+		{"JSON status string", `{"status": "OK", "msg": "well done"}`, VerdictAccepted, "well done"},
+		{"JSON status rejected", `{"verdict": "Rejected"}`, VerdictRejected, ""},
+		{"JSON status error", `{"status": "error", "message": "bad uuid"}`, VerdictRejected, "bad uuid"},
+		{"JSON status sentence", `{"status": "not accepted"}`, VerdictUnknown, ""},
+		{"JSON error key", `{"error": "Hash is not correct"}`, VerdictRejected, "Hash is not correct"},
+		{"JSON error overrides flag", `{"success": true, "error": "hash mismatch"}`, VerdictRejected, "hash mismatch"},
+		{"JSON null error", `{"accepted": true, "error": null}`, VerdictAccepted, ""},
+		{"JSON message only", `{"detail": "answer is wrong"}`, VerdictUnknown, "answer is wrong"},
+		{"JSON message with verdict-like words", `{"message": "validation failed: hash mismatch"}`, VerdictUnknown, "validation failed: hash mismatch"},
+		{"JSON result echo", `{"result": "0,1,2"}`, VerdictUnknown, ""},
+		{"JSON without verdict", `{"uuid": "abc"}`, VerdictUnknown, ""},
+		{"empty body", "", VerdictUnknown, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseSubmissionResult([]byte(tt.body))
+			if result.Verdict != tt.verdict {
+				t.Errorf("Expected verdict %s, got %s", tt.verdict, result.Verdict)
+			}
+			if result.Message != tt.message {
+				t.Errorf("Expected message %q, got %q", tt.message, result.Message)
+			}
+			if string(result.Raw) != tt.body {
+				t.Errorf("Raw body not preserved: %q", result.Raw)
+			}
+		})
+	}
+}
+
+func TestParseSubmissionResultFields(t *testing.T) {
+	result := parseSubmissionResult([]byte(`{"correct": true, "Score": "87.5", "attempt": 3}`))
+
+	if !result.Accepted() {
+		t.Errorf("Expected accepted verdict, got %s", result.Verdict)
+	}
+	if result.Score == nil || *result.Score != 87.5 {
+		t.Errorf("Expected score 87.5, got %v", result.Score)
+	}
+	if result.Fields["attempt"] != float64(3) {
+		t.Errorf("Expected extra fields to be kept, got %v", result.Fields)
+	}
+
+	if parseSubmissionResult([]byte("accepted")).Score != nil {
+		t.Error("Plain text response should have no score")
+	}
+}
@@ -0,0 +1,547 @@
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceEntry is one traced round trip. Bodies are captured in full.
+type TraceEntry struct {
+	Started time.Time
+	Timings TraceTimings
+
+	Method         string
+	URL            string
+	Proto          string
+	RequestHeader  http.Header
+	RequestBody    []byte
+	StatusCode     int
+	Status         string
+	ResponseHeader http.Header
+	ResponseBody   []byte
+	// Err is set when no response was received.
+	Err error
+}
+
+// TraceTimings splits a round trip into phases. Phases that did not happen,
+// e.g. DNS and Connect on a reused connection, are -1.
+type TraceTimings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	Send    time.Duration
+	Wait    time.Duration
+	Receive time.Duration
+	Total   time.Duration
+}
+
+// TraceSink receives traced round trips. Implementations must be safe for
+// concurrent use.
+type TraceSink interface {
+	Record(entry TraceEntry) error
+	Close() error
+}
+
+// TraceRedaction lists header names and top-level JSON body fields whose
+// values are masked before an entry reaches the sink. Body fields are masked
+// in URL query parameters of the same name as well.
+type TraceRedaction struct {
+	Headers    []string
+	BodyFields []string
+}
+
+const redactedValue = "[REDACTED]"
+
+func DefaultTraceRedaction() TraceRedaction {
+	return TraceRedaction{
+		Headers: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+	}
+}
+
+// ParseTraceRedaction extends the default redaction with a comma separated
+// list of "header:<name>" and "body:<field>" rules.
+func ParseTraceRedaction(value string) (TraceRedaction, error) {
+	redaction := DefaultTraceRedaction()
+	if value == "" {
+		return redaction, nil
+	}
+	for _, rule := range strings.Split(value, ",") {
+		kind, name, ok := strings.Cut(strings.TrimSpace(rule), ":")
+		switch {
+		case ok && kind == "header" && name != "":
+			redaction.Headers = append(redaction.Headers, name)
+		case ok && kind == "body" && name != "":
+			redaction.BodyFields = append(redaction.BodyFields, name)
+		default:
+			return TraceRedaction{}, fmt.Errorf("invalid redaction rule %q (expected header:<name> or body:<field>)", rule)
+		}
+	}
+	return redaction, nil
+}
+
+// withHeader returns a copy of r that also masks header.
+func (r TraceRedaction) withHeader(header string) TraceRedaction {
+	if slices.ContainsFunc(r.Headers, func(name string) bool { return strings.EqualFold(name, header) }) {
+		return r
+	}
+	r.Headers = append(slices.Clone(r.Headers), header)
+	return r
+}
+
+func (r TraceRedaction) apply(entry TraceEntry) TraceEntry {
+	entry.RequestHeader = r.header(entry.RequestHeader)
+	entry.ResponseHeader = r.header(entry.ResponseHeader)
+	entry.URL = r.query(entry.URL)
+	entry.RequestBody = r.body(entry.RequestBody)
+	entry.ResponseBody = r.body(entry.ResponseBody)
+	return entry
+}
+
+// query masks BodyFields in the URL parameters too, where the challenge
+// request carries them with ChallengeMethodQuery.
+func (r TraceRedaction) query(rawURL string) string {
+	if len(r.BodyFields) == 0 {
+		return rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.RawQuery == "" {
+		return rawURL
+	}
+	query := parsed.Query()
+	changed := false
+	for _, name := range r.BodyFields {
+		if values, ok := query[name]; ok {
+			for i := range values {
+				values[i] = redactedValue
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return rawURL
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+func (r TraceRedaction) header(header http.Header) http.Header {
+	if header == nil {
+		return nil
+	}
+	redacted := header.Clone()
+	for key, values := range redacted {
+		if slices.ContainsFunc(r.Headers, func(name string) bool { return strings.EqualFold(name, key) }) {
+			for i := range values {
+				values[i] = redactedValue
+			}
+		}
+	}
+	return redacted
+}
+
+func (r TraceRedaction) body(body []byte) []byte {
+	if len(r.BodyFields) == 0 {
+		return body
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return body
+	}
+	changed := false
+	for _, name := range r.BodyFields {
+		if _, ok := fields[name]; ok {
+			fields[name] = json.RawMessage(`"` + redactedValue + `"`)
+			changed = true
+		}
+	}
+	if !changed {
+		return body
+	}
+	redacted, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// ParseTraceSink creates the sink named by a DEBUG_HTTP value: "stderr" (or
+// any other non-empty value such as "1"), "file:<path>" for the text format
+// in a file, or "har:<path>" for a HAR 1.2 archive written on Close. An empty
+// spec disables tracing and returns nil.
+func ParseTraceSink(spec string) (TraceSink, error) {
+	kind, path, _ := strings.Cut(spec, ":")
+	switch {
+	case spec == "":
+		return nil, nil
+	case kind == "file":
+		return NewFileTraceSink(path)
+	case kind == "har":
+		if path == "" {
+			return nil, fmt.Errorf("har trace sink needs a path")
+		}
+		return NewHARTraceSink(path), nil
+	default:
+		return NewTextTraceSink(os.Stderr), nil
+	}
+}
+
+// textTraceSink writes a human readable dump of each round trip.
+type textTraceSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+func NewTextTraceSink(w io.Writer) TraceSink {
+	return &textTraceSink{w: w}
+}
+
+func NewFileTraceSink(path string) (TraceSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file trace sink needs a path")
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	return &textTraceSink{w: file, closer: file}, nil
+}
+
+func (s *textTraceSink) Record(entry TraceEntry) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--> %s %s %s\n", entry.Method, entry.URL, entry.Started.Format(time.RFC3339Nano))
+	entry.RequestHeader.Write(&buf)
+	if len(entry.RequestBody) > 0 {
+		fmt.Fprintf(&buf, "\n%s\n", entry.RequestBody)
+	}
+
+	t := entry.Timings
+	if entry.Err != nil {
+		fmt.Fprintf(&buf, "<-- error after %v: %v\n\n", t.Total, entry.Err)
+	} else {
+		fmt.Fprintf(&buf, "<-- %s %s (total %v, dns %s, connect %s, tls %s, send %v, wait %v, receive %v)\n",
+			entry.Proto, entry.Status, t.Total, phase(t.DNS), phase(t.Connect), phase(t.TLS), t.Send, t.Wait, t.Receive)
+		entry.ResponseHeader.Write(&buf)
+		fmt.Fprintf(&buf, "\n%s\n\n", entry.ResponseBody)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+func phase(d time.Duration) string {
+	if d < 0 {
+		return "-"
+	}
+	return d.String()
+}
+
+func (s *textTraceSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// tracingTransport records every round trip, including its timings, to a sink.
+type tracingTransport struct {
+	next      http.RoundTripper
+	sink      TraceSink
+	redaction TraceRedaction
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	var (
+		started                 = time.Now()
+		dnsStart, connectStart  time.Time
+		tlsStart, gotConn       time.Time
+		wroteRequest, firstByte time.Time
+		dns, connect, handshake = time.Duration(-1), time.Duration(-1), time.Duration(-1)
+	)
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { dns = time.Since(dnsStart) },
+		ConnectStart:      func(string, string) { connectStart = time.Now() },
+		ConnectDone:       func(string, string, error) { connect = time.Since(connectStart) },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			handshake = time.Since(tlsStart)
+		},
+		GotConn:              func(httptrace.GotConnInfo) { gotConn = time.Now() },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { wroteRequest = time.Now() },
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	entry := TraceEntry{
+		Started:       started,
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: req.Header,
+		RequestBody:   reqBody,
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		var respBody []byte
+		respBody, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+		entry.Proto = resp.Proto
+		entry.StatusCode = resp.StatusCode
+		entry.Status = resp.Status
+		entry.ResponseHeader = resp.Header
+		entry.ResponseBody = respBody
+	}
+	finished := time.Now()
+
+	if handshake >= 0 && connect >= 0 {
+		// HAR counts the TLS handshake as part of connecting.
+		connect += handshake
+	}
+	entry.Err = err
+	entry.Timings = TraceTimings{
+		DNS:     dns,
+		Connect: connect,
+		TLS:     handshake,
+		Send:    between(gotConn, wroteRequest),
+		Wait:    between(wroteRequest, firstByte),
+		Receive: between(firstByte, finished),
+		Total:   finished.Sub(started),
+	}
+
+	if recordErr := t.sink.Record(t.redaction.apply(entry)); recordErr != nil {
+		log.Printf("http trace: %v", recordErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func between(from, to time.Time) time.Duration {
+	if from.IsZero() || to.IsZero() {
+		return 0
+	}
+	return to.Sub(from)
+}
+
+// harTraceSink collects entries in memory and writes them as a HAR 1.2
+// archive, which browser devtools can import, on Close.
+type harTraceSink struct {
+	mu      sync.Mutex
+	path    string
+	entries []harEntry
+}
+
+func NewHARTraceSink(path string) TraceSink {
+	return &harTraceSink{path: path}
+}
+
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+func (s *harTraceSink) Record(entry TraceEntry) error {
+	har := harEntry{
+		StartedDateTime: entry.Started.Format("2006-01-02T15:04:05.000Z07:00"),
+		Time:            milliseconds(entry.Timings.Total),
+		Request: harRequest{
+			Method:      entry.Method,
+			URL:         entry.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(entry.RequestHeader),
+			QueryString: harQuery(entry.URL),
+			HeadersSize: -1,
+			BodySize:    len(entry.RequestBody),
+		},
+		Response: harResponse{
+			Status:      entry.StatusCode,
+			StatusText:  strings.TrimSpace(strings.TrimPrefix(entry.Status, fmt.Sprint(entry.StatusCode))),
+			HTTPVersion: entry.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(entry.ResponseHeader),
+			Content: harContent{
+				Size:     len(entry.ResponseBody),
+				MimeType: entry.ResponseHeader.Get("Content-Type"),
+				Text:     string(entry.ResponseBody),
+			},
+			HeadersSize: -1,
+			BodySize:    len(entry.ResponseBody),
+		},
+		Timings: harTimings{
+			Blocked: -1,
+			DNS:     milliseconds(entry.Timings.DNS),
+			Connect: milliseconds(entry.Timings.Connect),
+			Send:    milliseconds(entry.Timings.Send),
+			Wait:    milliseconds(entry.Timings.Wait),
+			Receive: milliseconds(entry.Timings.Receive),
+			SSL:     milliseconds(entry.Timings.TLS),
+		},
+	}
+	if entry.RequestBody != nil {
+		har.Request.PostData = &harPostData{
+			MimeType: entry.RequestHeader.Get("Content-Type"),
+			Text:     string(entry.RequestBody),
+		}
+	}
+	if entry.Err != nil {
+		har.Error = entry.Err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, har)
+	return nil
+}
+
+func (s *harTraceSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var archive harLog
+	archive.Log.Version = "1.2"
+	archive.Log.Creator = harCreator{Name: "torus-neighbors", Version: "1.0"}
+	archive.Log.Entries = s.entries
+	if archive.Log.Entries == nil {
+		archive.Log.Entries = []harEntry{}
+	}
+
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write HAR: %w", err)
+	}
+	return nil
+}
+
+func milliseconds(d time.Duration) float64 {
+	if d < 0 {
+		return -1
+	}
+	return float64(d) / float64(time.Millisecond)
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	pairs := []harNameValue{}
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			pairs = append(pairs, harNameValue{Name: key, Value: value})
+		}
+	}
+	return pairs
+}
+
+func harQuery(rawURL string) []harNameValue {
+	pairs := []harNameValue{}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+	for key, values := range parsed.Query() {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: key, Value: value})
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTextTraceSinkWithRedaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte(`{"uuid":"abc","set_x":"4","set_y":"4","set_z":"5"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	redaction := DefaultTraceRedaction()
+	redaction.BodyFields = []string{"user"}
+
+	client := NewClient(server.URL, WithTrace(NewTextTraceSink(&buf), redaction))
+	if _, err := client.GetChallenge("abc", "alice"); err != nil {
+		t.Fatalf("GetChallenge failed: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	trace := buf.String()
+	for _, expected := range []string{"--> POST " + server.URL + "/challenge-me-easy", "<-- HTTP/1.1 200 OK", `"set_x":"4"`, "wait "} {
+		if !strings.Contains(trace, expected) {
+			t.Errorf("Trace should contain %q:\n%s", expected, trace)
+		}
+	}
+	if strings.Contains(trace, "alice") || strings.Contains(trace, "session=secret") {
+		t.Errorf("Trace should be redacted:\n%s", trace)
+	}
+}
+
+func TestTraceRedactsQueryParameters(t *testing.T) {
+	server, _ := newFormServer(t, ChallengeMethodQuery)
+	defer server.Close()
+
+	var text bytes.Buffer
+	path := filepath.Join(t.TempDir(), "trace.har")
+	redaction := DefaultTraceRedaction()
+	redaction.BodyFields = []string{"user"}
+
+	for _, sink := range []TraceSink{NewTextTraceSink(&text), NewHARTraceSink(path)} {
+		client := NewClient(server.URL, WithChallengeMethod(ChallengeMethodQuery), WithTrace(sink, redaction))
+		if _, err := client.GetChallenge("test-uuid", "test user"); err != nil {
+			t.Fatalf("GetChallenge failed: %v", err)
+		}
+		if err := client.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+
+	if trace := text.String(); strings.Contains(trace, "test+user") || !strings.Contains(trace, "uuid=test-uuid") {
+		t.Errorf("User should be redacted from the traced URL:\n%s", trace)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("HAR not written: %v", err)
+	}
+	var archive harLog
+	if err := json.Unmarshal(data, &archive); err != nil {
+		t.Fatalf("HAR is not valid JSON: %v", err)
+	}
+	request := archive.Log.Entries[0].Request
+	if strings.Contains(request.URL, "test+user") {
+		t.Errorf("User should be redacted from the HAR URL, got %s", request.URL)
+	}
+	for _, param := range request.QueryString {
+		if param.Name == "user" && param.Value != redactedValue {
+			t.Errorf("Expected redacted user parameter, got %q", param.Value)
+		}
+	}
+}
+
+func TestHARTraceSink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closedAddr := listener.Addr().String()
+	listener.Close()
+
+	path := filepath.Join(t.TempDir(), "trace.har")
+	sink := NewHARTraceSink(path)
+
+	client := NewClient(server.URL, WithTrace(sink, DefaultTraceRedaction()))
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	failing := NewClient("http://"+closedAddr, WithRetryPolicy(NoRetry()), WithTrace(sink, DefaultTraceRedaction()))
+	if err := failing.Ping(); err == nil {
+		t.Fatal("Ping against a closed port should fail")
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("HAR not written: %v", err)
+	}
+	var archive harLog
+	if err := json.Unmarshal(data, &archive); err != nil {
+		t.Fatalf("HAR is not valid JSON: %v", err)
+	}
+
+	if archive.Log.Version != "1.2" || len(archive.Log.Entries) != 2 {
+		t.Fatalf("Expected HAR 1.2 with 2 entries, got version %q with %d", archive.Log.Version, len(archive.Log.Entries))
+	}
+	ok := archive.Log.Entries[0]
+	if ok.Response.Status != 200 || ok.Response.Content.Text != "pong" || ok.Request.Method != "GET" {
+		t.Errorf("Unexpected entry %+v", ok)
+	}
+	if ok.Time <= 0 || ok.Timings.Wait < 0 || ok.Timings.Connect < 0 {
+		t.Errorf("Expected timings for a fresh connection, got %+v (total %v)", ok.Timings, ok.Time)
+	}
+	if failed := archive.Log.Entries[1]; failed.Error == "" || failed.Response.Status != 0 {
+		t.Errorf("Expected failed entry with error, got %+v", failed)
+	}
+}
+
+func TestParseTraceSink(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		spec     string
+		disabled bool
+		valid    bool
+	}{
+		{"", true, true},
+		{"1", false, true},
+		{"stderr", false, true},
+		{"file:" + filepath.Join(dir, "trace.log"), false, true},
+		{"har:" + filepath.Join(dir, "trace.har"), false, true},
+		{"har:", true, false},
+		{"file:", true, false},
+	}
+
+	for _, tt := range tests {
+		sink, err := ParseTraceSink(tt.spec)
+		if (err == nil) != tt.valid || (sink == nil) != tt.disabled {
+			t.Errorf("ParseTraceSink(%q) = %v, %v", tt.spec, sink, err)
+		}
+		if sink != nil {
+			sink.Close()
+		}
+	}
+}
+
+func TestParseTraceRedaction(t *testing.T) {
+	redaction, err := ParseTraceRedaction("header:X-Api-Key, body:user")
+	if err != nil {
+		t.Fatalf("ParseTraceRedaction failed: %v", err)
+	}
+	if !strings.Contains(strings.Join(redaction.Headers, ","), "Authorization,") ||
+		redaction.Headers[len(redaction.Headers)-1] != "X-Api-Key" ||
+		len(redaction.BodyFields) != 1 || redaction.BodyFields[0] != "user" {
+		t.Errorf("Unexpected redaction %+v", redaction)
+	}
+
+	if _, err := ParseTraceRedaction("cookie"); err == nil {
+		t.Error("Rule without kind should be rejected")
+	}
+}
+
+func TestDebugHTTPEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "trace.log")
+	t.Setenv("DEBUG_HTTP", "file:"+path)
+
+	client := NewClient(server.URL)
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	client.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "--> GET "+server.URL+"/ping") {
+		t.Errorf("Expected ping in trace file, got %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// TransportConfig describes how the client reaches the API: which CAs it
+// trusts, which certificate it presents and which proxy it goes through.
+type TransportConfig struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+	// CertFile and KeyFile hold a PEM client certificate for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// MinTLSVersion is a tls.VersionTLS* constant; 0 keeps Go's default.
+	MinTLSVersion uint16
+	// ProxyURL overrides the HTTP_PROXY/HTTPS_PROXY environment variables.
+	ProxyURL string
+	// NoProxy lists hosts reached directly: "*", a host name (also matching
+	// its subdomains), ".domain" (subdomains only), an IP or a CIDR range,
+	// each optionally with ":port". It applies to environment proxies too.
+	NoProxy []string
+}
+
+// NewTransport builds an *http.Transport from config, starting from the
+// settings of http.DefaultTransport.
+func NewTransport(config TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig := &tls.Config{MinVersion: config.MinTLSVersion}
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		if config.CertFile == "" || config.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate needs both a certificate and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	proxy := http.ProxyFromEnvironment
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", config.ProxyURL)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	noProxy := config.NoProxy
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL, noProxy) {
+			return nil, nil
+		}
+		return proxy(req)
+	}
+
+	return transport, nil
+}
+
+// WithTransport replaces http.DefaultTransport as the client's base transport;
+// wrappers and tracing still apply on top of it.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+func bypassProxy(target *url.URL, noProxy []string) bool {
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[target.Scheme]
+	}
+
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "*" {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip := net.ParseIP(host); ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(entryHost, "."):
+			if strings.HasSuffix(host, entryHost) {
+				return true
+			}
+		case host == entryHost || strings.HasSuffix(host, "."+entryHost):
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTLSVersion parses "1.0" to "1.3".
+func ParseTLSVersion(value string) (uint16, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q, expected 1.0, 1.1, 1.2 or 1.3", value)
+}
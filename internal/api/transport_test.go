@@ -0,0 +1,228 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// writeServerCA stores the test server's self-signed certificate as a CA
+// bundle.
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	writePEM(t, path, "CERTIFICATE", server.Certificate().Raw)
+	return path
+}
+
+// newClientCert creates a self-signed client certificate and returns the
+// certificate and key files along with the parsed certificate.
+func newClientCert(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "torus-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile, cert
+}
+
+func newTLSClient(t *testing.T, server *httptest.Server, config TransportConfig) *Client {
+	t.Helper()
+	transport, err := NewTransport(config)
+	if err != nil {
+		t.Fatalf("NewTransport failed: %v", err)
+	}
+	return NewClient(server.URL, WithTransport(transport), WithRetryPolicy(NoRetry()))
+}
+
+func TestCustomCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if err := newTLSClient(t, server, TransportConfig{}).Ping(); err == nil {
+		t.Error("Ping should fail without trusting the test CA")
+	}
+
+	if err := newTLSClient(t, server, TransportConfig{CAFile: writeServerCA(t, server)}).Ping(); err != nil {
+		t.Errorf("Ping should succeed with the CA bundle, got %v", err)
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	certFile, keyFile, cert := newClientCert(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "torus-client" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	ca := writeServerCA(t, server)
+
+	if err := newTLSClient(t, server, TransportConfig{CAFile: ca}).Ping(); err == nil {
+		t.Error("Ping should fail without a client certificate")
+	}
+
+	config := TransportConfig{CAFile: ca, CertFile: certFile, KeyFile: keyFile}
+	if err := newTLSClient(t, server, config).Ping(); err != nil {
+		t.Errorf("Ping should succeed with the client certificate, got %v", err)
+	}
+}
+
+func TestMinTLSVersion(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	ca := writeServerCA(t, server)
+
+	if err := newTLSClient(t, server, TransportConfig{CAFile: ca, MinTLSVersion: tls.VersionTLS12}).Ping(); err != nil {
+		t.Errorf("TLS 1.2 should be accepted, got %v", err)
+	}
+
+	err := newTLSClient(t, server, TransportConfig{CAFile: ca, MinTLSVersion: tls.VersionTLS13}).Ping()
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) {
+		t.Errorf("Expected handshake failure against a TLS 1.2 server, got %v", err)
+	}
+}
+
+func TestNewTransportErrors(t *testing.T) {
+	certFile, _, _ := newClientCert(t)
+	emptyCA := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(emptyCA, []byte("no certificates here"), 0o600)
+
+	tests := []struct {
+		name   string
+		config TransportConfig
+	}{
+		{"missing CA file", TransportConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{"CA file without certificates", TransportConfig{CAFile: emptyCA}},
+		{"certificate without key", TransportConfig{CertFile: certFile}},
+		{"invalid proxy", TransportConfig{ProxyURL: "://proxy"}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewTransport(tt.config); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.URL.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	transport, err := NewTransport(TransportConfig{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatalf("NewTransport failed: %v", err)
+	}
+
+	client := NewClient("http://challenge.example:8080", WithTransport(transport), WithRetryPolicy(NoRetry()))
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping through the proxy failed: %v", err)
+	}
+	if proxiedHost != "challenge.example:8080" {
+		t.Errorf("Expected the proxy to see challenge.example:8080, got %q", proxiedHost)
+	}
+}
+
+func TestNoProxy(t *testing.T) {
+	transport, err := NewTransport(TransportConfig{
+		ProxyURL: "http://proxy.internal:3128",
+		NoProxy:  []string{"localhost", ".corp.example", "api.example:8443", "10.0.0.0/8"},
+	})
+	if err != nil {
+		t.Fatalf("NewTransport failed: %v", err)
+	}
+
+	tests := []struct {
+		target  string
+		proxied bool
+	}{
+		{"http://localhost:8080/ping", false},
+		{"https://svc.corp.example/ping", false},
+		{"https://corp.example/ping", true},
+		{"https://api.example:8443/ping", false},
+		{"https://api.example/ping", true},
+		{"http://10.1.2.3/ping", false},
+		{"http://11.1.2.3/ping", true},
+		{"https://zadanie.openmed.sk/ping", true},
+	}
+
+	for _, tt := range tests {
+		target, _ := url.Parse(tt.target)
+		proxyURL, err := transport.Proxy(&http.Request{URL: target})
+		if err != nil {
+			t.Fatalf("Proxy(%s) failed: %v", tt.target, err)
+		}
+		if (proxyURL != nil) != tt.proxied {
+			t.Errorf("Proxy(%s) = %v, expected proxied=%t", tt.target, proxyURL, tt.proxied)
+		}
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	if version, err := ParseTLSVersion("1.3"); err != nil || version != tls.VersionTLS13 {
+		t.Errorf("ParseTLSVersion(1.3) = %v, %v", version, err)
+	}
+	if version, err := ParseTLSVersion(""); err != nil || version != 0 {
+		t.Errorf("Empty version should keep the default, got %v, %v", version, err)
+	}
+	if _, err := ParseTLSVersion("TLS1.2"); err == nil {
+		t.Error("Unknown version should be rejected")
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValidationError is returned when a challenge response is well-formed JSON
+// but its content cannot be trusted. It lists every problem found, not just
+// the first.
+type ValidationError struct {
+	Endpoint string
+	Body     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s response: %s", e.Endpoint, strings.Join(e.Problems, "; "))
+}
+
+// ChallengeDecoder is implemented by challenge responses that decode and
+// check themselves; FetchChallengeContext uses it instead of json.Unmarshal.
+// DecodeChallenge returns a *ValidationError for content problems and any
+// other error for bodies that are not JSON at all.
+type ChallengeDecoder interface {
+	DecodeChallenge(body []byte, options DecodeOptions) error
+}
+
+type DecodeOptions struct {
+	// UUID is the requested challenge UUID, which the response must echo.
+	// Empty skips the check.
+	UUID string
+	// DisallowUnknownFields rejects responses with fields the decoder does
+	// not know, for catching API changes early.
+	DisallowUnknownFields bool
+}
+
+// WithDisallowUnknownFields makes the client reject challenge responses
+// carrying unknown fields.
+func WithDisallowUnknownFields() ClientOption {
+	return func(c *Client) {
+		c.disallowUnknownFields = true
+	}
+}
+
+var _ ChallengeDecoder = (*ChallengeResponse)(nil)
+
+// DecodeChallenge decodes an easy challenge. set_x, set_y and set_z may be
+// sent as JSON numbers or as strings and are stored as decimal strings.
+// Width and height must be positive and set_z an index into the w×h matrix.
+func (r *ChallengeResponse) DecodeChallenge(body []byte, options DecodeOptions) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return err
+	}
+
+	var problems []string
+	problemf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	var decoded ChallengeResponse
+	if raw, ok := fields["uuid"]; !ok {
+		problemf("missing field uuid")
+	} else if err := json.Unmarshal(raw, &decoded.UUID); err != nil {
+		problemf("uuid is %s, expected a string", raw)
+	} else if options.UUID != "" && decoded.UUID != options.UUID {
+		problemf("uuid %q does not match the requested %q", decoded.UUID, options.UUID)
+	}
+
+	width, widthOK := integerField(fields, "set_x", "width", 1, problemf)
+	height, heightOK := integerField(fields, "set_y", "height", 1, problemf)
+	index, indexOK := integerField(fields, "set_z", "target index", 0, problemf)
+	if widthOK && heightOK {
+		if width > math.MaxInt/height {
+			problemf("matrix of %d×%d cells is too large", width, height)
+		} else if indexOK && index >= width*height {
+			problemf("set_z %d is outside the %d×%d matrix (0..%d)", index, width, height, width*height-1)
+		}
+	}
+	decoded.SetX, decoded.SetY, decoded.SetZ = strconv.Itoa(width), strconv.Itoa(height), strconv.Itoa(index)
+
+	if options.DisallowUnknownFields {
+		var unknown []string
+		for name := range fields {
+			if !slices.Contains([]string{"uuid", "set_x", "set_y", "set_z"}, name) {
+				unknown = append(unknown, name)
+			}
+		}
+		slices.Sort(unknown)
+		for _, name := range unknown {
+			problemf("unknown field %s", name)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	*r = decoded
+	return nil
+}
+
+// integerField reads fields[name] as an integer of at least minimum, given
+// either as a JSON number or as a string holding one.
+func integerField(fields map[string]json.RawMessage, name, description string, minimum int, problemf func(string, ...any)) (int, bool) {
+	raw, ok := fields[name]
+	if !ok {
+		problemf("missing field %s (%s)", name, description)
+		return 0, false
+	}
+
+	text := string(bytes.TrimSpace(raw))
+	var quoted string
+	if json.Unmarshal(raw, &quoted) == nil {
+		text = quoted
+	} else if len(text) == 0 || !strings.ContainsAny(text[:1], "-0123456789") {
+		problemf("%s (%s) is %s, expected an integer or a string holding one", name, description, raw)
+		return 0, false
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		problemf("%s (%s) %q is not an integer", name, description, text)
+		return 0, false
+	}
+	if value < minimum {
+		problemf("%s (%s) is %d, must be at least %d", name, description, value, minimum)
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeChallenge(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		options  DecodeOptions
+		expected ChallengeResponse
+		problems []string
+	}{
+		{
+			name:     "strings",
+			body:     `{"uuid":"u1","set_x":"4","set_y":"3","set_z":"11"}`,
+			options:  DecodeOptions{UUID: "u1"},
+			expected: ChallengeResponse{UUID: "u1", SetX: "4", SetY: "3", SetZ: "11"},
+		},
+		{
+			name:     "numbers",
+			body:     `{"uuid":"u1","set_x":4,"set_y":3,"set_z":0}`,
+			options:  DecodeOptions{UUID: "u1"},
+			expected: ChallengeResponse{UUID: "u1", SetX: "4", SetY: "3", SetZ: "0"},
+		},
+		{
+			name:     "unknown fields tolerated by default",
+			body:     `{"uuid":"u1","set_x":"4","set_y":"4","set_z":"5","hint":"wrap"}`,
+			expected: ChallengeResponse{UUID: "u1", SetX: "4", SetY: "4", SetZ: "5"},
+		},
+		{
+			name:     "unknown fields rejected",
+			body:     `{"uuid":"u1","set_x":"4","set_y":"4","set_z":"5","zeta":1,"hint":"wrap"}`,
+			options:  DecodeOptions{DisallowUnknownFields: true},
+			problems: []string{"unknown field hint", "unknown field zeta"},
+		},
+		{
+			name:    "every problem is reported",
+			body:    `{"uuid":"other","set_x":"four","set_y":0}`,
+			options: DecodeOptions{UUID: "u1"},
+			problems: []string{
+				`uuid "other" does not match the requested "u1"`,
+				`set_x (width) "four" is not an integer`,
+				"set_y (height) is 0, must be at least 1",
+				"missing field set_z (target index)",
+			},
+		},
+		{
+			name:     "index out of range",
+			body:     `{"uuid":"u1","set_x":"4","set_y":"4","set_z":16}`,
+			problems: []string{"set_z 16 is outside the 4×4 matrix (0..15)"},
+		},
+		{
+			name: "wrong types",
+			body: `{"uuid":7,"set_x":true,"set_y":1.5,"set_z":"-1"}`,
+			problems: []string{
+				"uuid is 7, expected a string",
+				"set_x (width) is true, expected an integer or a string holding one",
+				`set_y (height) "1.5" is not an integer`,
+				"set_z (target index) is -1, must be at least 0",
+			},
+		},
+		{
+			name:     "overflowing dimensions",
+			body:     `{"uuid":"u1","set_x":"4294967296","set_y":"4294967296","set_z":"0"}`,
+			problems: []string{"matrix of 4294967296×4294967296 cells is too large"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response ChallengeResponse
+			err := response.DecodeChallenge([]byte(tt.body), tt.options)
+
+			if tt.problems == nil {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if response != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, response)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected *ValidationError, got %T: %v", err, err)
+			}
+			if !reflect.DeepEqual(validationErr.Problems, tt.problems) {
+				t.Errorf("Expected problems %q, got %q", tt.problems, validationErr.Problems)
+			}
+			if response != (ChallengeResponse{}) {
+				t.Errorf("Response should be left untouched on failure, got %+v", response)
+			}
+		})
+	}
+}
+
+func TestGetChallengeValidation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"uuid":"stale-uuid","set_x":4,"set_y":4,"set_z":5,"debug":true}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).GetChallenge("test-uuid", "test-user")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *ValidationError, got %T: %v", err, err)
+	}
+	if validationErr.Endpoint != "POST /challenge-me-easy" || len(validationErr.Problems) != 1 {
+		t.Errorf("Expected a single UUID problem on the challenge endpoint, got %v", validationErr)
+	}
+
+	_, err = NewClient(server.URL, WithDisallowUnknownFields()).GetChallenge("stale-uuid", "test-user")
+	if !errors.As(err, &validationErr) || validationErr.Problems[0] != "unknown field debug" {
+		t.Errorf("Expected unknown field problem, got %v", err)
+	}
+
+	response, err := NewClient(server.URL).GetChallenge("stale-uuid", "test-user")
+	if err != nil || response.SetZ != "5" {
+		t.Errorf("Expected numeric fields to be accepted, got %+v, %v", response, err)
+	}
+}
@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// Torus64 is a row-major torus whose dimensions and indices are uint64. It
+// never allocates anything proportional to the grid, so neighbor queries work
+// for any grid with at most 2^64-1 cells.
+type Torus64 struct {
+	width  uint64
+	height uint64
+	total  uint64
+}
+
+func NewTorus64(width, height uint64) (*Torus64, error) {
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("width and height must be positive integers, got width=%d, height=%d", width, height)
+	}
+
+	hi, total := bits.Mul64(width, height)
+	if hi != 0 {
+		return nil, fmt.Errorf("torus %dx%d has more elements than fit in a uint64, use BigTorus", width, height)
+	}
+
+	return &Torus64{
+		width:  width,
+		height: height,
+		total:  total,
+	}, nil
+}
+
+func (t *Torus64) Dimensions() (width, height uint64) {
+	return t.width, t.height
+}
+
+func (t *Torus64) TotalElements() uint64 {
+	return t.total
+}
+
+func (t *Torus64) IsValidIndex(index uint64) bool {
+	return index < t.total
+}
+
+func (t *Torus64) IndexToCoordinates(index uint64) (row, col uint64, err error) {
+	if !t.IsValidIndex(index) {
+		return 0, 0, fmt.Errorf("index %d is out of bounds for torus %dx%d", index, t.width, t.height)
+	}
+	return index / t.width, index % t.width, nil
+}
+
+// CoordinatesToIndex wraps row+rowOffset and col+colOffset around the torus.
+func (t *Torus64) CoordinatesToIndex(row, col uint64, rowOffset, colOffset int) uint64 {
+	return shiftWrap64(row, rowOffset, t.height)*t.width + shiftWrap64(col, colOffset, t.width)
+}
+
+// FindNeighbors returns the Moore neighbors of index in AllDirections order.
+func (t *Torus64) FindNeighbors(index uint64) ([]uint64, error) {
+	row, col, err := t.IndexToCoordinates(index)
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := make([]uint64, 0, len(AllDirections))
+	for _, direction := range AllDirections {
+		neighbors = append(neighbors, t.CoordinatesToIndex(row, col, direction.RowOffset, direction.ColOffset))
+	}
+	return neighbors, nil
+}
+
+// TorusMatrix converts to the int based matrix when the grid fits.
+func (t *Torus64) TorusMatrix() (*TorusMatrix, error) {
+	if t.width > math.MaxInt || t.height > math.MaxInt {
+		return nil, fmt.Errorf("torus %dx%d does not fit in an int matrix", t.width, t.height)
+	}
+	return NewTorusMatrix(int(t.width), int(t.height))
+}
+
+func shiftWrap64(value uint64, offset int, size uint64) uint64 {
+	value %= size
+	if offset >= 0 {
+		step := uint64(offset) % size
+		if value >= size-step {
+			return value - (size - step)
+		}
+		return value + step
+	}
+
+	step := (uint64(-(offset + 1)) + 1) % size
+	if value >= step {
+		return value - step
+	}
+	return value + (size - step)
+}
+
+// BigTorus is a row-major torus with arbitrary precision dimensions. Only
+// O(1)-sized queries are supported; hashing is refused via ErrHashInfeasible
+// unless the extended matrix is within MaxHashableCells.
+type BigTorus struct {
+	width  *big.Int
+	height *big.Int
+	total  *big.Int
+}
+
+func NewBigTorus(width, height *big.Int) (*BigTorus, error) {
+	if width == nil || height == nil || width.Sign() <= 0 || height.Sign() <= 0 {
+		return nil, fmt.Errorf("width and height must be positive integers, got width=%v, height=%v", width, height)
+	}
+
+	return &BigTorus{
+		width:  new(big.Int).Set(width),
+		height: new(big.Int).Set(height),
+		total:  new(big.Int).Mul(width, height),
+	}, nil
+}
+
+// ParseBigTorus builds a BigTorus from base-10 strings such as the set_x and
+// set_y values sent by the challenge API.
+func ParseBigTorus(width, height string) (*BigTorus, error) {
+	w, ok := new(big.Int).SetString(width, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid width value '%s'", width)
+	}
+	h, ok := new(big.Int).SetString(height, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid height value '%s'", height)
+	}
+	return NewBigTorus(w, h)
+}
+
+func (t *BigTorus) Dimensions() (width, height *big.Int) {
+	return new(big.Int).Set(t.width), new(big.Int).Set(t.height)
+}
+
+func (t *BigTorus) TotalElements() *big.Int {
+	return new(big.Int).Set(t.total)
+}
+
+func (t *BigTorus) IsValidIndex(index *big.Int) bool {
+	return index != nil && index.Sign() >= 0 && index.Cmp(t.total) < 0
+}
+
+func (t *BigTorus) IndexToCoordinates(index *big.Int) (row, col *big.Int, err error) {
+	if !t.IsValidIndex(index) {
+		return nil, nil, fmt.Errorf("index %v is out of bounds for torus %sx%s", index, t.width, t.height)
+	}
+
+	row, col = new(big.Int).QuoRem(index, t.width, new(big.Int))
+	return row, col, nil
+}
+
+func (t *BigTorus) CoordinatesToIndex(row, col *big.Int) *big.Int {
+	wrappedRow := new(big.Int).Mod(row, t.height)
+	wrappedCol := new(big.Int).Mod(col, t.width)
+
+	index := wrappedRow.Mul(wrappedRow, t.width)
+	return index.Add(index, wrappedCol)
+}
+
+// FindNeighbors returns the Moore neighbors of index in AllDirections order.
+func (t *BigTorus) FindNeighbors(index *big.Int) ([]*big.Int, error) {
+	row, col, err := t.IndexToCoordinates(index)
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := make([]*big.Int, 0, len(AllDirections))
+	for _, direction := range AllDirections {
+		neighborRow := new(big.Int).Add(row, big.NewInt(int64(direction.RowOffset)))
+		neighborCol := new(big.Int).Add(col, big.NewInt(int64(direction.ColOffset)))
+		neighbors = append(neighbors, t.CoordinatesToIndex(neighborRow, neighborCol))
+	}
+	return neighbors, nil
+}
+
+// NewMatrixHasher returns a hasher for the torus, or an error wrapping
+// ErrHashInfeasible when the extended matrix is too large to serialize.
+func (t *BigTorus) NewMatrixHasher() (*MatrixHasher, error) {
+	if err := checkHashFeasible(t.width, t.height, UniformPadding(1)); err != nil {
+		return nil, err
+	}
+
+	matrix, err := NewTorusMatrix(int(t.width.Int64()), int(t.height.Int64()))
+	if err != nil {
+		return nil, err
+	}
+	return NewMatrixHasher(matrix), nil
+}
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestNewTorusMatrixOverflow(t *testing.T) {
+	if _, err := NewTorusMatrix(math.MaxInt/2, 3); err == nil {
+		t.Error("Expected overflow error")
+	}
+	if _, err := NewTorusMatrix(math.MaxInt, 1); err != nil {
+		t.Errorf("Unexpected error for largest single row: %v", err)
+	}
+}
+
+func TestCoordinatesToIndexLargeMatrix(t *testing.T) {
+	matrix, err := NewTorusMatrix(math.MaxInt, 1)
+	if err != nil {
+		t.Fatalf("Failed to create matrix: %v", err)
+	}
+
+	if got := matrix.CoordinatesToIndex(0, -1); got != math.MaxInt-1 {
+		t.Errorf("Expected %d, got %d", math.MaxInt-1, got)
+	}
+	if got := matrix.CoordinatesToIndex(0, math.MinInt); got != math.MaxInt-1 {
+		t.Errorf("Expected %d, got %d", math.MaxInt-1, got)
+	}
+}
+
+func TestTorus64MatchesTorusMatrix(t *testing.T) {
+	matrix, _ := NewTorusMatrix(5, 4)
+	torus, err := NewTorus64(5, 4)
+	if err != nil {
+		t.Fatalf("Failed to create torus: %v", err)
+	}
+
+	finder := NewNeighborFinder(matrix)
+	for index := 0; index < matrix.TotalElements(); index++ {
+		expected, _ := finder.FindNeighbors(index)
+		actual, err := torus.FindNeighbors(uint64(index))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for i := range expected {
+			if uint64(expected[i]) != actual[i] {
+				t.Fatalf("Index %d: expected %v, got %v", index, expected, actual)
+			}
+		}
+	}
+}
+
+func TestTorus64Huge(t *testing.T) {
+	torus, err := NewTorus64(math.MaxUint32+1, math.MaxUint32)
+	if err != nil {
+		t.Fatalf("Failed to create torus: %v", err)
+	}
+
+	neighbors, err := torus.FindNeighbors(0)
+	if err != nil {
+		t.Fatalf("FindNeighbors failed: %v", err)
+	}
+
+	last := torus.TotalElements() - 1
+	if neighbors[0] != last {
+		t.Errorf("Expected top-left neighbor %d, got %d", last, neighbors[0])
+	}
+
+	if _, err := NewTorus64(math.MaxUint64, 2); err == nil {
+		t.Error("Expected overflow error")
+	}
+	if _, err := torus.TorusMatrix(); err == nil {
+		t.Error("Expected conversion error for oversized torus")
+	}
+}
+
+func TestShiftWrap64(t *testing.T) {
+	tests := []struct {
+		value    uint64
+		offset   int
+		size     uint64
+		expected uint64
+	}{
+		{0, -1, 4, 3},
+		{3, 1, 4, 0},
+		{2, 0, 4, 2},
+		{0, -5, 4, 3},
+		{math.MaxUint64 - 1, 1, math.MaxUint64, 0},
+		{0, math.MinInt, 10, 2},
+	}
+
+	for _, tt := range tests {
+		if got := shiftWrap64(tt.value, tt.offset, tt.size); got != tt.expected {
+			t.Errorf("shiftWrap64(%d, %d, %d) = %d, expected %d", tt.value, tt.offset, tt.size, got, tt.expected)
+		}
+	}
+}
+
+func TestBigTorusNeighbors(t *testing.T) {
+	small, err := ParseBigTorus("4", "4")
+	if err != nil {
+		t.Fatalf("Failed to create torus: %v", err)
+	}
+
+	neighbors, err := small.FindNeighbors(big.NewInt(0))
+	if err != nil {
+		t.Fatalf("FindNeighbors failed: %v", err)
+	}
+	actual := make([]int64, len(neighbors))
+	for i, n := range neighbors {
+		actual[i] = n.Int64()
+	}
+	if expected := []int64{15, 12, 13, 3, 1, 7, 4, 5}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	huge, err := ParseBigTorus("100000000000000000000000000000", "300000000000000000000000000000")
+	if err != nil {
+		t.Fatalf("Failed to create torus: %v", err)
+	}
+
+	last := new(big.Int).Sub(huge.TotalElements(), big.NewInt(1))
+	neighbors, err = huge.FindNeighbors(last)
+	if err != nil {
+		t.Fatalf("FindNeighbors failed: %v", err)
+	}
+	if neighbors[7].Sign() != 0 {
+		t.Errorf("Expected bottom-right neighbor of last cell to be 0, got %v", neighbors[7])
+	}
+
+	if _, err := huge.FindNeighbors(huge.TotalElements()); err == nil {
+		t.Error("Expected error for out of bounds index")
+	}
+	if _, err := ParseBigTorus("abc", "1"); err == nil {
+		t.Error("Expected error for invalid width")
+	}
+	if _, err := ParseBigTorus("1", "-3"); err == nil {
+		t.Error("Expected error for negative height")
+	}
+}
+
+func TestBigTorusHashFeasibility(t *testing.T) {
+	small, _ := ParseBigTorus("4", "4")
+	hasher, err := small.NewMatrixHasher()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := hasher.ValidateExpectedHash("hJVz5fi5z2YecMNLsihGJQHBpAGUAYitNUOFGmjBg38="); err != nil {
+		t.Error(err)
+	}
+
+	huge, _ := ParseBigTorus("10000000000", "10000000000")
+	if _, err := huge.NewMatrixHasher(); !errors.Is(err, ErrHashInfeasible) {
+		t.Errorf("Expected ErrHashInfeasible, got %v", err)
+	}
+
+	matrix, _ := NewTorusMatrix(1<<21, 1<<21)
+	if err := NewMatrixHasher(matrix).CheckFeasible(); !errors.Is(err, ErrHashInfeasible) {
+		t.Errorf("Expected ErrHashInfeasible, got %v", err)
+	}
+}
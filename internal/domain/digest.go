@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"hash/fnv"
+	"strings"
+)
+
+type HashAlgorithm string
+
+const (
+	SHA256   HashAlgorithm = "sha256"
+	SHA512   HashAlgorithm = "sha512"
+	SHA3_256 HashAlgorithm = "sha3-256"
+	// SHA1 is only meant for legacy checks.
+	SHA1 HashAlgorithm = "sha1"
+	// CRC32, CRC64 and FNV64a are fast non-cryptographic fingerprints.
+	CRC32  HashAlgorithm = "crc32"
+	CRC64  HashAlgorithm = "crc64"
+	FNV64a HashAlgorithm = "fnv64a"
+)
+
+var hashAlgorithms = map[HashAlgorithm]func() hash.Hash{
+	SHA256:   sha256.New,
+	SHA512:   sha512.New,
+	SHA3_256: func() hash.Hash { return sha3.New256() },
+	SHA1:     sha1.New,
+	CRC32:    func() hash.Hash { return crc32.NewIEEE() },
+	CRC64:    func() hash.Hash { return crc64.New(crc64.MakeTable(crc64.ECMA)) },
+	FNV64a:   func() hash.Hash { return fnv.New64a() },
+}
+
+func (a HashAlgorithm) New() (hash.Hash, error) {
+	newHash, ok := hashAlgorithms[a]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash algorithm %q, expected one of %s", string(a), HashAlgorithmNames())
+	}
+	return newHash(), nil
+}
+
+func HashAlgorithmNames() string {
+	return strings.Join([]string{
+		string(SHA256), string(SHA512), string(SHA3_256), string(SHA1),
+		string(CRC32), string(CRC64), string(FNV64a),
+	}, ", ")
+}
+
+func ParseHashAlgorithm(name string) (HashAlgorithm, error) {
+	algorithm := HashAlgorithm(strings.ToLower(name))
+	if _, err := algorithm.New(); err != nil {
+		return "", err
+	}
+	return algorithm, nil
+}
+
+type DigestEncoding string
+
+const (
+	EncodingBase64    DigestEncoding = "base64"
+	EncodingBase64URL DigestEncoding = "base64url"
+	EncodingHex       DigestEncoding = "hex"
+	EncodingBase32    DigestEncoding = "base32"
+)
+
+func (e DigestEncoding) Encode(digest []byte) (string, error) {
+	switch e {
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(digest), nil
+	case EncodingBase64URL:
+		return base64.URLEncoding.EncodeToString(digest), nil
+	case EncodingHex:
+		return hex.EncodeToString(digest), nil
+	case EncodingBase32:
+		return base32.StdEncoding.EncodeToString(digest), nil
+	default:
+		return "", fmt.Errorf("unknown digest encoding %q, expected one of %s", string(e), DigestEncodingNames())
+	}
+}
+
+func DigestEncodingNames() string {
+	return strings.Join([]string{
+		string(EncodingBase64), string(EncodingBase64URL), string(EncodingHex), string(EncodingBase32),
+	}, ", ")
+}
+
+func ParseDigestEncoding(name string) (DigestEncoding, error) {
+	encoding := DigestEncoding(strings.ToLower(name))
+	if _, err := encoding.Encode(nil); err != nil {
+		return "", err
+	}
+	return encoding, nil
+}
+
+// HasherConfig selects the digest algorithm, its text encoding and the border
+// of the extended matrix. Empty fields fall back to the challenge defaults:
+// SHA256, EncodingBase64 and a one cell border on every side.
+type HasherConfig struct {
+	Algorithm HashAlgorithm
+	Encoding  DigestEncoding
+	Padding   *Padding
+}
+
+func DefaultHasherConfig() HasherConfig {
+	padding := UniformPadding(1)
+	return HasherConfig{
+		Algorithm: SHA256,
+		Encoding:  EncodingBase64,
+		Padding:   &padding,
+	}
+}
+
+func (c HasherConfig) withDefaults() HasherConfig {
+	defaults := DefaultHasherConfig()
+	if c.Algorithm == "" {
+		c.Algorithm = defaults.Algorithm
+	}
+	if c.Encoding == "" {
+		c.Encoding = defaults.Encoding
+	}
+	if c.Padding == nil {
+		c.Padding = defaults.Padding
+	} else {
+		padding := *c.Padding
+		c.Padding = &padding
+	}
+	return c
+}
+
+func (c HasherConfig) Validate() error {
+	c = c.withDefaults()
+	if _, err := c.Algorithm.New(); err != nil {
+		return err
+	}
+	if _, err := c.Encoding.Encode(nil); err != nil {
+		return err
+	}
+	return c.Padding.Validate()
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash/crc32"
+	"testing"
+)
+
+func TestHasherConfigAlgorithms(t *testing.T) {
+	matrix, _ := NewTorusMatrix(4, 4)
+	matrixString, err := NewMatrixHasher(matrix).GenerateMatrixString()
+	if err != nil {
+		t.Fatalf("Failed to serialize matrix: %v", err)
+	}
+	data := []byte(matrixString)
+
+	sha512Sum := sha512.Sum512(data)
+	crc := crc32.ChecksumIEEE(data)
+	crcBytes := []byte{byte(crc >> 24), byte(crc >> 16), byte(crc >> 8), byte(crc)}
+
+	tests := []struct {
+		name     string
+		config   HasherConfig
+		expected string
+	}{
+		{"zero config is the challenge default", HasherConfig{}, "hJVz5fi5z2YecMNLsihGJQHBpAGUAYitNUOFGmjBg38="},
+		{"explicit default", DefaultHasherConfig(), "hJVz5fi5z2YecMNLsihGJQHBpAGUAYitNUOFGmjBg38="},
+		{"sha512 base64", HasherConfig{Algorithm: SHA512}, base64.StdEncoding.EncodeToString(sha512Sum[:])},
+		{"crc32 hex", HasherConfig{Algorithm: CRC32, Encoding: EncodingHex}, hex.EncodeToString(crcBytes)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher, err := NewMatrixHasherWithConfig(matrix, tt.config)
+			if err != nil {
+				t.Fatalf("Failed to create hasher: %v", err)
+			}
+			if hash := mustCalculateHash(t, hasher); hash != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, hash)
+			}
+		})
+	}
+}
+
+func TestHasherConfigURLSafeEncoding(t *testing.T) {
+	// The 2x4 digest has both a '+' and a '/' in standard base64.
+	matrix, _ := NewTorusMatrix(2, 4)
+
+	tests := []struct {
+		encoding DigestEncoding
+		expected string
+	}{
+		{EncodingBase64, "HpU4HVnvchBXH/18sKNHDmkIAq5t8I+b3fYiIAO77xA="},
+		{EncodingBase64URL, "HpU4HVnvchBXH_18sKNHDmkIAq5t8I-b3fYiIAO77xA="},
+	}
+	for _, tt := range tests {
+		hasher, err := NewMatrixHasherWithConfig(matrix, HasherConfig{Algorithm: SHA256, Encoding: tt.encoding})
+		if err != nil {
+			t.Fatalf("Failed to create hasher: %v", err)
+		}
+		if hash := mustCalculateHash(t, hasher); hash != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.encoding, tt.expected, hash)
+		}
+	}
+}
+
+func TestHasherConfigEveryCombination(t *testing.T) {
+	matrix, _ := NewTorusMatrix(3, 2)
+	algorithms := []HashAlgorithm{SHA256, SHA512, SHA3_256, SHA1, CRC32, CRC64, FNV64a}
+	encodings := []DigestEncoding{EncodingBase64, EncodingBase64URL, EncodingHex, EncodingBase32}
+
+	for _, algorithm := range algorithms {
+		for _, encoding := range encodings {
+			hasher, err := NewMatrixHasherWithConfig(matrix, HasherConfig{Algorithm: algorithm, Encoding: encoding})
+			if err != nil {
+				t.Fatalf("%s/%s: unexpected error: %v", algorithm, encoding, err)
+			}
+			if mustCalculateHash(t, hasher) == "" {
+				t.Errorf("%s/%s: empty digest", algorithm, encoding)
+			}
+		}
+	}
+}
+
+func TestHasherConfigInvalid(t *testing.T) {
+	matrix, _ := NewTorusMatrix(4, 4)
+
+	if _, err := NewMatrixHasherWithConfig(matrix, HasherConfig{Algorithm: "md4"}); err == nil {
+		t.Error("Expected error for unknown algorithm")
+	}
+	if _, err := NewMatrixHasherWithConfig(matrix, HasherConfig{Encoding: "base58"}); err == nil {
+		t.Error("Expected error for unknown encoding")
+	}
+}
+
+func TestParseHashAlgorithmAndEncoding(t *testing.T) {
+	if algorithm, err := ParseHashAlgorithm("SHA3-256"); err != nil || algorithm != SHA3_256 {
+		t.Errorf("Expected sha3-256, got %q (%v)", algorithm, err)
+	}
+	if _, err := ParseHashAlgorithm("md5"); err == nil {
+		t.Error("Expected error for unsupported algorithm")
+	}
+
+	if encoding, err := ParseDigestEncoding("HEX"); err != nil || encoding != EncodingHex {
+		t.Errorf("Expected hex, got %q (%v)", encoding, err)
+	}
+	if _, err := ParseDigestEncoding("ascii85"); err == nil {
+		t.Error("Expected error for unsupported encoding")
+	}
+}
@@ -1,28 +1,130 @@
 package domain
 
 import (
-	"crypto/sha256"
-	"encoding/base64"
+	"bufio"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"math/big"
 	"strconv"
 	"strings"
 )
 
+const hashBufferSize = 32 * 1024
+
+// MaxHashableCells caps the size of the extended matrix a hasher will accept.
+const MaxHashableCells = 1 << 40
+
+var ErrHashInfeasible = errors.New("extended matrix is too large to hash")
+
 type MatrixHasher struct {
 	matrix *TorusMatrix
+	config HasherConfig
+}
+
+// Padding is the number of extra cells generated on each side of the matrix
+// in the extended matrix.
+type Padding struct {
+	Top    int
+	Right  int
+	Bottom int
+	Left   int
+}
+
+func UniformPadding(k int) Padding {
+	return Padding{Top: k, Right: k, Bottom: k, Left: k}
+}
+
+func (p Padding) Validate() error {
+	if p.Top < 0 || p.Right < 0 || p.Bottom < 0 || p.Left < 0 {
+		return fmt.Errorf("padding must be non-negative, got top=%d right=%d bottom=%d left=%d",
+			p.Top, p.Right, p.Bottom, p.Left)
+	}
+	return nil
+}
+
+// ParsePadding accepts either a single width "k" or four comma separated
+// widths in "top,right,bottom,left" order.
+func ParsePadding(value string) (Padding, error) {
+	fields := strings.Split(value, ",")
+	if len(fields) != 1 && len(fields) != 4 {
+		return Padding{}, fmt.Errorf("padding must be 'k' or 'top,right,bottom,left', got %q", value)
+	}
+
+	widths := make([]int, len(fields))
+	for i, field := range fields {
+		width, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return Padding{}, fmt.Errorf("invalid padding %q: %w", value, err)
+		}
+		widths[i] = width
+	}
+
+	padding := UniformPadding(widths[0])
+	if len(widths) == 4 {
+		padding = Padding{Top: widths[0], Right: widths[1], Bottom: widths[2], Left: widths[3]}
+	}
+	return padding, padding.Validate()
 }
 
 func NewMatrixHasher(matrix *TorusMatrix) *MatrixHasher {
 	return &MatrixHasher{
 		matrix: matrix,
+		config: DefaultHasherConfig(),
+	}
+}
+
+func NewMatrixHasherWithConfig(matrix *TorusMatrix, config HasherConfig) (*MatrixHasher, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid hasher config: %w", err)
 	}
+
+	return &MatrixHasher{
+		matrix: matrix,
+		config: config.withDefaults(),
+	}, nil
 }
 
-func (mh *MatrixHasher) GenerateWrappedMatrix() [][]int {
+func (mh *MatrixHasher) Config() HasherConfig {
+	return mh.config.withDefaults()
+}
+
+// CheckFeasible reports ErrHashInfeasible when the extended matrix exceeds
+// MaxHashableCells.
+func (mh *MatrixHasher) CheckFeasible() error {
 	width, height := mh.matrix.Dimensions()
+	return checkHashFeasible(big.NewInt(int64(width)), big.NewInt(int64(height)), mh.padding())
+}
 
-	extendedHeight := height + 2
-	extendedWidth := width + 2
+func checkHashFeasible(width, height *big.Int, padding Padding) error {
+	extendedWidth := new(big.Int).Add(width, big.NewInt(int64(padding.Left)))
+	extendedWidth.Add(extendedWidth, big.NewInt(int64(padding.Right)))
+	extendedHeight := new(big.Int).Add(height, big.NewInt(int64(padding.Top)))
+	extendedHeight.Add(extendedHeight, big.NewInt(int64(padding.Bottom)))
+	cells := new(big.Int).Mul(extendedWidth, extendedHeight)
+
+	if cells.Cmp(big.NewInt(MaxHashableCells)) > 0 {
+		return fmt.Errorf("%w: %sx%s extended matrix has %s cells, limit is %d",
+			ErrHashInfeasible, extendedWidth, extendedHeight, cells, MaxHashableCells)
+	}
+	return nil
+}
+
+func (mh *MatrixHasher) padding() Padding {
+	return *mh.config.Padding
+}
+
+// GenerateWrappedMatrix pads the matrix with the configured border (one cell
+// on every side by default) using the matrix topology. Border cells that fall
+// off the surface hold OffGridIndex. Like CalculateHash it refuses matrices
+// too large to hash with ErrHashInfeasible.
+func (mh *MatrixHasher) GenerateWrappedMatrix() ([][]int, error) {
+	if err := mh.CheckFeasible(); err != nil {
+		return nil, err
+	}
+	extendedWidth, extendedHeight := mh.extendedDimensions()
+	padding := mh.padding()
 
 	extended := make([][]int, extendedHeight)
 	for i := range extended {
@@ -31,44 +133,111 @@ func (mh *MatrixHasher) GenerateWrappedMatrix() [][]int {
 
 	for extRow := 0; extRow < extendedHeight; extRow++ {
 		for extCol := 0; extCol < extendedWidth; extCol++ {
-			originalRow := extRow - 1
-			originalCol := extCol - 1
+			originalRow := extRow - padding.Top
+			originalCol := extCol - padding.Left
 
 			wrappedIndex := mh.matrix.CoordinatesToIndex(originalRow, originalCol)
 			extended[extRow][extCol] = wrappedIndex
 		}
 	}
 
-	return extended
+	return extended, nil
+}
+
+// GenerateMatrixString returns the serialization written by WriteMatrix, or
+// ErrHashInfeasible for matrices too large to hold in memory.
+func (mh *MatrixHasher) GenerateMatrixString() (string, error) {
+	var builder strings.Builder
+	if err := mh.WriteMatrix(&builder); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// WriteMatrix streams the canonical serialization of the extended matrix
+// (comma separated cells, newline separated rows, no trailing newline) to w
+// one cell at a time, so memory use does not depend on the matrix size.
+func (mh *MatrixHasher) WriteMatrix(w io.Writer) error {
+	if err := mh.CheckFeasible(); err != nil {
+		return err
+	}
+	return mh.writeCells(w, 0, mh.extendedElements())
+}
+
+func (mh *MatrixHasher) extendedDimensions() (width, height int) {
+	width, height = mh.matrix.Dimensions()
+	padding := mh.padding()
+	return width + padding.Left + padding.Right, height + padding.Top + padding.Bottom
+}
+
+func (mh *MatrixHasher) extendedElements() int {
+	width, height := mh.extendedDimensions()
+	return width * height
 }
 
-func (mh *MatrixHasher) GenerateMatrixString() string {
-	wrapped := mh.GenerateWrappedMatrix()
+// writeCells serializes the extended cells [from, to) in row-major order,
+// including the separator that precedes each cell.
+func (mh *MatrixHasher) writeCells(w io.Writer, from, to int) error {
+	extendedWidth, _ := mh.extendedDimensions()
+	padding := mh.padding()
+
+	extRow, extCol := from/extendedWidth, from%extendedWidth
+	buf := make([]byte, 0, 24)
+	for cell := from; cell < to; cell++ {
+		buf = buf[:0]
+		if extCol > 0 {
+			buf = append(buf, ',')
+		} else if extRow > 0 {
+			buf = append(buf, '\n')
+		}
+		buf = strconv.AppendInt(buf, int64(mh.matrix.CoordinatesToIndex(extRow-padding.Top, extCol-padding.Left)), 10)
 
-	var rows []string
-	for _, row := range wrapped {
-		var elements []string
-		for _, element := range row {
-			elements = append(elements, strconv.Itoa(element))
+		if _, err := w.Write(buf); err != nil {
+			return fmt.Errorf("failed to write matrix row %d: %w", extRow, err)
+		}
+
+		extCol++
+		if extCol == extendedWidth {
+			extRow, extCol = extRow+1, 0
 		}
-		rows = append(rows, strings.Join(elements, ","))
 	}
 
-	return strings.Join(rows, "\n")
+	return nil
 }
 
-func (mh *MatrixHasher) CalculateHash() string {
-	matrixString := mh.GenerateMatrixString()
+// CalculateHash digests the extended matrix. It returns ErrHashInfeasible
+// when the matrix exceeds MaxHashableCells instead of hashing partial input.
+func (mh *MatrixHasher) CalculateHash() (string, error) {
+	hasher := mh.newHash()
 
-	hasher := sha256.New()
-	hasher.Write([]byte(matrixString))
-	hashBytes := hasher.Sum(nil)
+	writer := bufio.NewWriterSize(hasher, hashBufferSize)
+	if err := mh.WriteMatrix(writer); err != nil {
+		return "", err
+	}
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return mh.encode(hasher.Sum(nil)), nil
+}
 
-	return base64.StdEncoding.EncodeToString(hashBytes)
+// newHash and encode never fail because the config is validated on
+// construction.
+func (mh *MatrixHasher) newHash() hash.Hash {
+	hasher, _ := mh.config.Algorithm.New()
+	return hasher
+}
+
+func (mh *MatrixHasher) encode(digest []byte) string {
+	encoded, _ := mh.config.Encoding.Encode(digest)
+	return encoded
 }
 
 func (mh *MatrixHasher) ValidateExpectedHash(expected string) error {
-	calculated := mh.CalculateHash()
+	calculated, err := mh.CalculateHash()
+	if err != nil {
+		return err
+	}
 	if calculated != expected {
 		return fmt.Errorf("hash mismatch: expected %s, got %s", expected, calculated)
 	}
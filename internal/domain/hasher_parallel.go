@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// parallelBandCells is the number of extended cells serialized per band.
+// Bands may span or split rows; only their order matters for the digest.
+const parallelBandCells = 16 * 1024
+
+type hashBand struct {
+	from   int
+	to     int
+	result chan []byte
+}
+
+// CalculateHashParallel serializes bands of the extended matrix on workers
+// goroutines and feeds them into the digest in order, yielding exactly the
+// same hash as CalculateHash. At most 2*workers bands are buffered at once.
+// workers <= 0 uses GOMAXPROCS.
+func (mh *MatrixHasher) CalculateHashParallel(ctx context.Context, workers int) (string, error) {
+	if err := mh.CheckFeasible(); err != nil {
+		return "", err
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	total := mh.extendedElements()
+	jobs := make(chan hashBand)
+	order := make(chan hashBand, 2*workers)
+
+	go func() {
+		defer close(jobs)
+		defer close(order)
+
+		for from := 0; from < total; from += parallelBandCells {
+			band := hashBand{
+				from:   from,
+				to:     min(from+parallelBandCells, total),
+				result: make(chan []byte, 1),
+			}
+
+			select {
+			case order <- band:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- band:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for band := range jobs {
+				var buf bytes.Buffer
+				mh.writeCells(&buf, band.from, band.to)
+				band.result <- buf.Bytes()
+			}
+		}()
+	}
+
+	hasher := mh.newHash()
+	for band := range order {
+		select {
+		case data := <-band.result:
+			hasher.Write(data)
+		case <-ctx.Done():
+			return "", fmt.Errorf("parallel hash cancelled: %w", ctx.Err())
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("parallel hash cancelled: %w", err)
+	}
+
+	return mh.encode(hasher.Sum(nil)), nil
+}
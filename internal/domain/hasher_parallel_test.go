@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCalculateHashParallelMatchesSequential(t *testing.T) {
+	shapes := [][2]int{{4, 4}, {1, 1}, {3, 2}, {500, 300}, {20000, 2}}
+
+	for _, shape := range shapes {
+		matrix, _ := NewTorusMatrix(shape[0], shape[1])
+		hasher := NewMatrixHasher(matrix)
+		expected := mustCalculateHash(t, hasher)
+
+		for _, workers := range []int{0, 1, 3, 8} {
+			actual, err := hasher.CalculateHashParallel(context.Background(), workers)
+			if err != nil {
+				t.Fatalf("%dx%d with %d workers: unexpected error: %v", shape[0], shape[1], workers, err)
+			}
+			if actual != expected {
+				t.Errorf("%dx%d with %d workers: expected %s, got %s", shape[0], shape[1], workers, expected, actual)
+			}
+		}
+	}
+}
+
+func TestCalculateHashParallelCancelled(t *testing.T) {
+	matrix, _ := NewTorusMatrix(2000, 2000)
+	hasher := NewMatrixHasher(matrix)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := hasher.CalculateHashParallel(ctx, 4); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestCalculateHashParallelDeadlineWhileHashing(t *testing.T) {
+	// About a thousand bands, far more than the ordering buffer holds, so the
+	// deadline fires while bands are in flight.
+	matrix, _ := NewTorusMatrix(4000, 4000)
+	hasher := NewMatrixHasher(matrix)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	hash, err := hasher.CalculateHashParallel(ctx, 4)
+	if !errors.Is(err, context.DeadlineExceeded) || hash != "" {
+		t.Errorf("Expected context.DeadlineExceeded and no digest, got %q, %v", hash, err)
+	}
+}
+
+func TestCalculateHashParallelInfeasible(t *testing.T) {
+	matrix, _ := NewTorusMatrix(1<<21, 1<<21)
+	hasher := NewMatrixHasher(matrix)
+
+	if _, err := hasher.CalculateHashParallel(context.Background(), 2); !errors.Is(err, ErrHashInfeasible) {
+		t.Errorf("Expected ErrHashInfeasible, got %v", err)
+	}
+}
+
+func BenchmarkCalculateHashParallel(b *testing.B) {
+	matrix, _ := NewTorusMatrix(1000, 1000)
+	hasher := NewMatrixHasher(matrix)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hasher.CalculateHashParallel(context.Background(), 0)
+	}
+}
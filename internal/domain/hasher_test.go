@@ -1,6 +1,11 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -56,7 +61,7 @@ func TestGenerateWrappedMatrix(t *testing.T) {
 			}
 
 			hasher := NewMatrixHasher(matrix)
-			wrapped := hasher.GenerateWrappedMatrix()
+			wrapped := mustGenerateWrappedMatrix(t, hasher)
 
 			if len(wrapped) != len(tt.expected) {
 				t.Errorf("Expected %d rows, got %d", len(tt.expected), len(wrapped))
@@ -86,7 +91,10 @@ func TestGenerateMatrixString(t *testing.T) {
 	}
 
 	hasher := NewMatrixHasher(matrix)
-	matrixString := hasher.GenerateMatrixString()
+	matrixString, err := hasher.GenerateMatrixString()
+	if err != nil {
+		t.Fatalf("Failed to serialize matrix: %v", err)
+	}
 
 	expectedLines := []string{
 		"15,12,13,14,15,12",
@@ -110,7 +118,7 @@ func TestCalculateHash(t *testing.T) {
 	}
 
 	hasher := NewMatrixHasher(matrix)
-	hash := hasher.CalculateHash()
+	hash := mustCalculateHash(t, hasher)
 
 	expected := "hJVz5fi5z2YecMNLsihGJQHBpAGUAYitNUOFGmjBg38="
 
@@ -146,11 +154,222 @@ func TestHashConsistency(t *testing.T) {
 
 	hasher := NewMatrixHasher(matrix)
 
-	hash1 := hasher.CalculateHash()
-	hash2 := hasher.CalculateHash()
-	hash3 := hasher.CalculateHash()
+	hash1 := mustCalculateHash(t, hasher)
+	hash2 := mustCalculateHash(t, hasher)
+	hash3 := mustCalculateHash(t, hasher)
 
 	if hash1 != hash2 || hash2 != hash3 {
 		t.Errorf("Hash calculation is not consistent: %s, %s, %s", hash1, hash2, hash3)
 	}
 }
+
+func mustCalculateHash(t *testing.T, hasher *MatrixHasher) string {
+	t.Helper()
+	hash, err := hasher.CalculateHash()
+	if err != nil {
+		t.Fatalf("Failed to calculate hash: %v", err)
+	}
+	return hash
+}
+
+func mustGenerateWrappedMatrix(tb testing.TB, hasher *MatrixHasher) [][]int {
+	tb.Helper()
+	wrapped, err := hasher.GenerateWrappedMatrix()
+	if err != nil {
+		tb.Fatalf("Failed to generate wrapped matrix: %v", err)
+	}
+	return wrapped
+}
+
+func TestCalculateHashRefusesInfeasibleMatrix(t *testing.T) {
+	matrix, _ := NewTorusMatrix(1<<21, 1<<21)
+	hasher := NewMatrixHasher(matrix)
+
+	if hash, err := hasher.CalculateHash(); !errors.Is(err, ErrHashInfeasible) || hash != "" {
+		t.Errorf("Expected ErrHashInfeasible and no digest, got %q, %v", hash, err)
+	}
+	if s, err := hasher.GenerateMatrixString(); !errors.Is(err, ErrHashInfeasible) || s != "" {
+		t.Errorf("Expected ErrHashInfeasible and no serialization, got %d bytes, %v", len(s), err)
+	}
+	if err := hasher.ValidateExpectedHash("47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="); !errors.Is(err, ErrHashInfeasible) {
+		t.Errorf("The digest of empty input must not validate, got %v", err)
+	}
+	if wrapped, err := hasher.GenerateWrappedMatrix(); !errors.Is(err, ErrHashInfeasible) || wrapped != nil {
+		t.Errorf("Expected ErrHashInfeasible and no matrix, got %d rows, %v", len(wrapped), err)
+	}
+
+	small, _ := NewTorusMatrix(2, 2)
+	padding := UniformPadding(1 << 30)
+	padded, err := NewMatrixHasherWithConfig(small, HasherConfig{Padding: &padding})
+	if err != nil {
+		t.Fatalf("Failed to create hasher: %v", err)
+	}
+	if _, err := padded.GenerateWrappedMatrix(); !errors.Is(err, ErrHashInfeasible) {
+		t.Errorf("Expected ErrHashInfeasible for a huge padding, got %v", err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriteMatrixMatchesMaterialized(t *testing.T) {
+	shapes := [][2]int{{4, 4}, {1, 1}, {3, 2}, {17, 5}, {128, 3}}
+
+	for _, shape := range shapes {
+		matrix, _ := NewTorusMatrix(shape[0], shape[1])
+		hasher := NewMatrixHasher(matrix)
+
+		if streamed, materialized := mustCalculateHash(t, hasher), materializedHash(t, hasher); streamed != materialized {
+			t.Errorf("%dx%d: streamed hash %s differs from materialized hash %s", shape[0], shape[1], streamed, materialized)
+		}
+	}
+
+	matrix, _ := NewTorusMatrix(4, 4)
+	if err := NewMatrixHasher(matrix).WriteMatrix(failingWriter{}); err == nil {
+		t.Error("Expected write error to be reported")
+	}
+}
+
+// materializedHash is the original implementation that builds the whole
+// extended matrix and its string form before hashing.
+func materializedHash(tb testing.TB, mh *MatrixHasher) string {
+	var rows []string
+	for _, row := range mustGenerateWrappedMatrix(tb, mh) {
+		var elements []string
+		for _, element := range row {
+			elements = append(elements, strconv.Itoa(element))
+		}
+		rows = append(rows, strings.Join(elements, ","))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(rows, "\n")))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func BenchmarkCalculateHashStreaming(b *testing.B) {
+	matrix, _ := NewTorusMatrix(1000, 1000)
+	hasher := NewMatrixHasher(matrix)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hasher.CalculateHash()
+	}
+}
+
+func BenchmarkCalculateHashMaterialized(b *testing.B) {
+	matrix, _ := NewTorusMatrix(1000, 1000)
+	hasher := NewMatrixHasher(matrix)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		materializedHash(b, hasher)
+	}
+}
+
+func TestGenerateWrappedMatrixPadding(t *testing.T) {
+	matrix, _ := NewTorusMatrix(3, 2)
+
+	tests := []struct {
+		name     string
+		padding  Padding
+		expected [][]int
+	}{
+		{
+			name:     "no padding",
+			padding:  UniformPadding(0),
+			expected: [][]int{{0, 1, 2}, {3, 4, 5}},
+		},
+		{
+			name:    "uniform padding of two",
+			padding: UniformPadding(2),
+			expected: [][]int{
+				{1, 2, 0, 1, 2, 0, 1},
+				{4, 5, 3, 4, 5, 3, 4},
+				{1, 2, 0, 1, 2, 0, 1},
+				{4, 5, 3, 4, 5, 3, 4},
+				{1, 2, 0, 1, 2, 0, 1},
+				{4, 5, 3, 4, 5, 3, 4},
+			},
+		},
+		{
+			name:    "asymmetric padding",
+			padding: Padding{Top: 1, Right: 0, Bottom: 0, Left: 2},
+			expected: [][]int{
+				{4, 5, 3, 4, 5},
+				{1, 2, 0, 1, 2},
+				{4, 5, 3, 4, 5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			padding := tt.padding
+			hasher, err := NewMatrixHasherWithConfig(matrix, HasherConfig{Padding: &padding})
+			if err != nil {
+				t.Fatalf("Failed to create hasher: %v", err)
+			}
+
+			if wrapped := mustGenerateWrappedMatrix(t, hasher); !reflect.DeepEqual(wrapped, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, wrapped)
+			}
+			if streamed, materialized := mustCalculateHash(t, hasher), materializedHash(t, hasher); streamed != materialized {
+				t.Errorf("Streamed hash %s differs from materialized hash %s", streamed, materialized)
+			}
+		})
+	}
+}
+
+func TestPaddingOneIsDefault(t *testing.T) {
+	matrix, _ := NewTorusMatrix(4, 4)
+	padding := UniformPadding(1)
+
+	hasher, err := NewMatrixHasherWithConfig(matrix, HasherConfig{Padding: &padding})
+	if err != nil {
+		t.Fatalf("Failed to create hasher: %v", err)
+	}
+	if err := hasher.ValidateExpectedHash("hJVz5fi5z2YecMNLsihGJQHBpAGUAYitNUOFGmjBg38="); err != nil {
+		t.Error(err)
+	}
+
+	negative := Padding{Top: -1}
+	if _, err := NewMatrixHasherWithConfig(matrix, HasherConfig{Padding: &negative}); err == nil {
+		t.Error("Expected error for negative padding")
+	}
+}
+
+func TestParsePadding(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Padding
+		expectError bool
+	}{
+		{"1", UniformPadding(1), false},
+		{"0", UniformPadding(0), false},
+		{"1,2,3,4", Padding{Top: 1, Right: 2, Bottom: 3, Left: 4}, false},
+		{" 2 , 0 , 2 , 0 ", Padding{Top: 2, Right: 0, Bottom: 2, Left: 0}, false},
+		{"1,2", Padding{}, true},
+		{"-1", Padding{}, true},
+		{"x", Padding{}, true},
+	}
+
+	for _, tt := range tests {
+		padding, err := ParsePadding(tt.input)
+		if tt.expectError {
+			if err == nil {
+				t.Errorf("Expected error for %q", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", tt.input, err)
+			continue
+		}
+		if padding != tt.expected {
+			t.Errorf("For %q expected %+v, got %+v", tt.input, tt.expected, padding)
+		}
+	}
+}
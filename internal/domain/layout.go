@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// IndexLayout numbers the cells of a width x height grid. Index and
+// Coordinates only receive in-range values and must be inverse bijections.
+type IndexLayout interface {
+	Name() string
+	Validate(width, height int) error
+	Index(row, col, width, height int) int
+	Coordinates(index, width, height int) (row, col int)
+}
+
+// RowMajorLayout numbers cells left to right, top to bottom.
+type RowMajorLayout struct{}
+
+func (RowMajorLayout) Name() string { return "row-major" }
+
+func (RowMajorLayout) Validate(width, height int) error { return nil }
+
+func (RowMajorLayout) Index(row, col, width, height int) int {
+	return row*width + col
+}
+
+func (RowMajorLayout) Coordinates(index, width, height int) (int, int) {
+	return index / width, index % width
+}
+
+// ColumnMajorLayout numbers cells top to bottom, left to right.
+type ColumnMajorLayout struct{}
+
+func (ColumnMajorLayout) Name() string { return "column-major" }
+
+func (ColumnMajorLayout) Validate(width, height int) error { return nil }
+
+func (ColumnMajorLayout) Index(row, col, width, height int) int {
+	return col*height + row
+}
+
+func (ColumnMajorLayout) Coordinates(index, width, height int) (int, int) {
+	return index % height, index / height
+}
+
+// MortonLayout interleaves the column and row bits (column bit first). Both
+// dimensions must be powers of two; on non-square grids the surplus high bits
+// of the longer axis are appended after the interleaved part.
+type MortonLayout struct{}
+
+func (MortonLayout) Name() string { return "morton" }
+
+func (MortonLayout) Validate(width, height int) error {
+	if !isPowerOfTwo(width) || !isPowerOfTwo(height) {
+		return fmt.Errorf("morton layout requires power-of-two dimensions, got %dx%d", width, height)
+	}
+	return nil
+}
+
+func (MortonLayout) Index(row, col, width, height int) int {
+	colBits, rowBits := log2(width), log2(height)
+
+	index, shift := 0, 0
+	for bit := 0; bit < colBits || bit < rowBits; bit++ {
+		if bit < colBits {
+			index |= (col >> bit & 1) << shift
+			shift++
+		}
+		if bit < rowBits {
+			index |= (row >> bit & 1) << shift
+			shift++
+		}
+	}
+	return index
+}
+
+func (MortonLayout) Coordinates(index, width, height int) (int, int) {
+	colBits, rowBits := log2(width), log2(height)
+
+	row, col, shift := 0, 0, 0
+	for bit := 0; bit < colBits || bit < rowBits; bit++ {
+		if bit < colBits {
+			col |= (index >> shift & 1) << bit
+			shift++
+		}
+		if bit < rowBits {
+			row |= (index >> shift & 1) << bit
+			shift++
+		}
+	}
+	return row, col
+}
+
+// HilbertLayout follows the Hilbert curve starting at the top-left cell. The
+// grid must be square with a power-of-two side.
+type HilbertLayout struct{}
+
+func (HilbertLayout) Name() string { return "hilbert" }
+
+func (HilbertLayout) Validate(width, height int) error {
+	if width != height || !isPowerOfTwo(width) {
+		return fmt.Errorf("hilbert layout requires a square power-of-two grid, got %dx%d", width, height)
+	}
+	return nil
+}
+
+func (HilbertLayout) Index(row, col, width, height int) int {
+	x, y := col, row
+	index := 0
+	for s := width / 2; s > 0; s /= 2 {
+		rx, ry := 0, 0
+		if x&s != 0 {
+			rx = 1
+		}
+		if y&s != 0 {
+			ry = 1
+		}
+		index += s * s * ((3 * rx) ^ ry)
+		x, y = hilbertRotate(width, x, y, rx, ry)
+	}
+	return index
+}
+
+func (HilbertLayout) Coordinates(index, width, height int) (int, int) {
+	x, y := 0, 0
+	for s := 1; s < width; s *= 2 {
+		rx := 1 & (index / 2)
+		ry := 1 & (index ^ rx)
+		x, y = hilbertRotate(s, x, y, rx, ry)
+		x += s * rx
+		y += s * ry
+		index /= 4
+	}
+	return y, x
+}
+
+func hilbertRotate(n, x, y, rx, ry int) (int, int) {
+	if ry == 0 {
+		if rx == 1 {
+			x = n - 1 - x
+			y = n - 1 - y
+		}
+		x, y = y, x
+	}
+	return x, y
+}
+
+var layouts = []IndexLayout{
+	RowMajorLayout{},
+	ColumnMajorLayout{},
+	MortonLayout{},
+	HilbertLayout{},
+}
+
+func ParseIndexLayout(name string) (IndexLayout, error) {
+	names := make([]string, len(layouts))
+	for i, layout := range layouts {
+		if strings.EqualFold(layout.Name(), name) {
+			return layout, nil
+		}
+		names[i] = layout.Name()
+	}
+	return nil, fmt.Errorf("unknown index layout %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// ConvertIndex renumbers a cell of a width x height grid from one layout to
+// another.
+func ConvertIndex(index, width, height int, from, to IndexLayout) (int, error) {
+	if width <= 0 || height <= 0 {
+		return 0, fmt.Errorf("width and height must be positive integers, got width=%d, height=%d", width, height)
+	}
+	if index < 0 || index >= width*height {
+		return 0, fmt.Errorf("index %d is out of bounds for matrix %dx%d", index, width, height)
+	}
+	if err := from.Validate(width, height); err != nil {
+		return 0, err
+	}
+	if err := to.Validate(width, height); err != nil {
+		return 0, err
+	}
+
+	row, col := from.Coordinates(index, width, height)
+	return to.Index(row, col, width, height), nil
+}
+
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
+
+func log2(n int) int {
+	return bits.Len(uint(n)) - 1
+}
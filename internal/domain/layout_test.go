@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLayoutRoundTrip(t *testing.T) {
+	tests := []struct {
+		layout IndexLayout
+		width  int
+		height int
+	}{
+		{RowMajorLayout{}, 5, 3},
+		{ColumnMajorLayout{}, 5, 3},
+		{MortonLayout{}, 8, 8},
+		{MortonLayout{}, 8, 2},
+		{MortonLayout{}, 2, 16},
+		{HilbertLayout{}, 8, 8},
+		{HilbertLayout{}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		if err := tt.layout.Validate(tt.width, tt.height); err != nil {
+			t.Fatalf("%s %dx%d: unexpected validation error: %v", tt.layout.Name(), tt.width, tt.height, err)
+		}
+
+		seen := make(map[int]bool)
+		for row := 0; row < tt.height; row++ {
+			for col := 0; col < tt.width; col++ {
+				index := tt.layout.Index(row, col, tt.width, tt.height)
+				if index < 0 || index >= tt.width*tt.height || seen[index] {
+					t.Fatalf("%s %dx%d: (%d,%d) mapped to invalid or repeated index %d",
+						tt.layout.Name(), tt.width, tt.height, row, col, index)
+				}
+				seen[index] = true
+
+				backRow, backCol := tt.layout.Coordinates(index, tt.width, tt.height)
+				if backRow != row || backCol != col {
+					t.Errorf("%s %dx%d: (%d,%d) -> %d -> (%d,%d)",
+						tt.layout.Name(), tt.width, tt.height, row, col, index, backRow, backCol)
+				}
+			}
+		}
+	}
+}
+
+func TestLayoutValidation(t *testing.T) {
+	if err := (MortonLayout{}).Validate(6, 4); err == nil {
+		t.Error("Expected morton error for non power-of-two width")
+	}
+	if err := (HilbertLayout{}).Validate(8, 4); err == nil {
+		t.Error("Expected hilbert error for non-square grid")
+	}
+	if _, err := NewTorusMatrixWithConfig(3, 3, MatrixConfig{Layout: HilbertLayout{}}); err == nil {
+		t.Error("Expected matrix construction to reject invalid layout")
+	}
+}
+
+func TestHilbertCurveIsContinuous(t *testing.T) {
+	const side = 16
+	layout := HilbertLayout{}
+
+	prevRow, prevCol := layout.Coordinates(0, side, side)
+	if prevRow != 0 || prevCol != 0 {
+		t.Fatalf("Hilbert curve should start at (0,0), got (%d,%d)", prevRow, prevCol)
+	}
+
+	for index := 1; index < side*side; index++ {
+		row, col := layout.Coordinates(index, side, side)
+		if abs(row-prevRow)+abs(col-prevCol) != 1 {
+			t.Fatalf("Step %d jumps from (%d,%d) to (%d,%d)", index, prevRow, prevCol, row, col)
+		}
+		prevRow, prevCol = row, col
+	}
+}
+
+func TestMortonOrder(t *testing.T) {
+	matrix, _ := NewTorusMatrixWithConfig(4, 4, MatrixConfig{Layout: MortonLayout{}})
+
+	expected := [][]int{
+		{0, 1, 4, 5},
+		{2, 3, 6, 7},
+		{8, 9, 12, 13},
+		{10, 11, 14, 15},
+	}
+	for row := range expected {
+		for col, want := range expected[row] {
+			if got := matrix.CoordinatesToIndex(row, col); got != want {
+				t.Errorf("(%d,%d): expected %d, got %d", row, col, want, got)
+			}
+		}
+	}
+}
+
+func TestFindNeighborsColumnMajor(t *testing.T) {
+	matrix, err := NewTorusMatrixWithConfig(4, 4, MatrixConfig{Layout: ColumnMajorLayout{}})
+	if err != nil {
+		t.Fatalf("Failed to create matrix: %v", err)
+	}
+
+	neighbors, err := NewNeighborFinder(matrix).FindNeighbors(5)
+	if err != nil {
+		t.Fatalf("FindNeighbors failed: %v", err)
+	}
+
+	expected := []int{0, 4, 8, 1, 9, 2, 6, 10}
+	if !reflect.DeepEqual(neighbors, expected) {
+		t.Errorf("Expected neighbors %v, got %v", expected, neighbors)
+	}
+}
+
+func TestConvertIndex(t *testing.T) {
+	matrix, _ := NewTorusMatrix(4, 4)
+
+	tests := []struct {
+		index    int
+		to       IndexLayout
+		expected int
+	}{
+		{5, RowMajorLayout{}, 5},
+		{1, ColumnMajorLayout{}, 4},
+		{2, MortonLayout{}, 4},
+		{4, HilbertLayout{}, 3},
+	}
+
+	for _, tt := range tests {
+		actual, err := matrix.ConvertIndex(tt.index, tt.to)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if actual != tt.expected {
+			t.Errorf("Converting %d to %s: expected %d, got %d", tt.index, tt.to.Name(), tt.expected, actual)
+		}
+	}
+
+	if _, err := matrix.ConvertIndex(16, ColumnMajorLayout{}); err == nil {
+		t.Error("Expected error for out of bounds index")
+	}
+
+	rect, _ := NewTorusMatrix(3, 2)
+	if _, err := rect.ConvertIndex(0, HilbertLayout{}); err == nil {
+		t.Error("Expected error for incompatible target layout")
+	}
+}
+
+func TestParseIndexLayout(t *testing.T) {
+	for _, name := range []string{"row-major", "column-major", "morton", "hilbert"} {
+		layout, err := ParseIndexLayout(name)
+		if err != nil || layout.Name() != name {
+			t.Errorf("Failed to parse %q: %v", name, err)
+		}
+	}
+	if _, err := ParseIndexLayout("snake"); err == nil {
+		t.Error("Expected error for unknown layout")
+	}
+}
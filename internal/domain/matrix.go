@@ -2,21 +2,61 @@ package domain
 
 import (
 	"fmt"
+	"math"
 )
 
+type MatrixConfig struct {
+	// Topology defaults to TorusTopology when nil.
+	Topology Topology
+	// Layout defaults to RowMajorLayout when nil.
+	Layout IndexLayout
+}
+
 type TorusMatrix struct {
-	width  int
-	height int
+	width    int
+	height   int
+	topology Topology
+	layout   IndexLayout
 }
 
 func NewTorusMatrix(width, height int) (*TorusMatrix, error) {
+	return NewTorusMatrixWithConfig(width, height, MatrixConfig{})
+}
+
+// NewTwistedTorusMatrix creates a helical torus, see TwistedTorusTopology.
+func NewTwistedTorusMatrix(width, height, colShift, rowShift int) (*TorusMatrix, error) {
+	return NewTorusMatrixWithConfig(width, height, MatrixConfig{
+		Topology: TwistedTorusTopology{ColShift: colShift, RowShift: rowShift},
+	})
+}
+
+func NewTorusMatrixWithConfig(width, height int, config MatrixConfig) (*TorusMatrix, error) {
 	if width <= 0 || height <= 0 {
 		return nil, fmt.Errorf("width and height must be positive integers, got width=%d, height=%d", width, height)
 	}
 
+	if width > math.MaxInt/height {
+		return nil, fmt.Errorf("matrix %dx%d has more elements than fit in an int, use BigTorus or Torus64", width, height)
+	}
+
+	topology := config.Topology
+	if topology == nil {
+		topology = TorusTopology{}
+	}
+
+	layout := config.Layout
+	if layout == nil {
+		layout = RowMajorLayout{}
+	}
+	if err := layout.Validate(width, height); err != nil {
+		return nil, fmt.Errorf("invalid index layout: %w", err)
+	}
+
 	return &TorusMatrix{
-		width:  width,
-		height: height,
+		width:    width,
+		height:   height,
+		topology: topology,
+		layout:   layout,
 	}, nil
 }
 
@@ -24,6 +64,14 @@ func (tm *TorusMatrix) Dimensions() (width, height int) {
 	return tm.width, tm.height
 }
 
+func (tm *TorusMatrix) Topology() Topology {
+	return tm.topology
+}
+
+func (tm *TorusMatrix) Layout() IndexLayout {
+	return tm.layout
+}
+
 func (tm *TorusMatrix) TotalElements() int {
 	return tm.width * tm.height
 }
@@ -33,16 +81,32 @@ func (tm *TorusMatrix) IndexToCoordinates(index int) (row, col int, err error) {
 		return 0, 0, fmt.Errorf("index %d is out of bounds for matrix %dx%d", index, tm.width, tm.height)
 	}
 
-	row = index / tm.width
-	col = index % tm.width
+	row, col = tm.layout.Coordinates(index, tm.width, tm.height)
 	return row, col, nil
 }
 
+// CoordinatesToIndex maps coordinates through the matrix topology and returns
+// OffGridIndex when they do not land on the surface.
 func (tm *TorusMatrix) CoordinatesToIndex(row, col int) int {
-	wrappedRow := ((row % tm.height) + tm.height) % tm.height
-	wrappedCol := ((col % tm.width) + tm.width) % tm.width
+	index, ok := tm.ResolveCoordinates(row, col)
+	if !ok {
+		return OffGridIndex
+	}
+	return index
+}
+
+func (tm *TorusMatrix) ResolveCoordinates(row, col int) (int, bool) {
+	wrappedRow, wrappedCol, ok := tm.topology.Resolve(row, col, tm.width, tm.height)
+	if !ok {
+		return OffGridIndex, false
+	}
+
+	return tm.layout.Index(wrappedRow, wrappedCol, tm.width, tm.height), true
+}
 
-	return wrappedRow*tm.width + wrappedCol
+// ConvertIndex renumbers a cell of this matrix into another layout.
+func (tm *TorusMatrix) ConvertIndex(index int, to IndexLayout) (int, error) {
+	return ConvertIndex(index, tm.width, tm.height, tm.layout, to)
 }
 
 func (tm *TorusMatrix) IsValidIndex(index int) bool {
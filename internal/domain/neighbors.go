@@ -19,14 +19,105 @@ var AllDirections = []NeighborDirection{
 	{1, 1, "BottomRight"},
 }
 
+// DuplicatePolicy decides what happens when distinct stencil offsets land on
+// the same cell, which happens on tori smaller than the stencil.
+type DuplicatePolicy int
+
+const (
+	// DuplicatesKeep reports every offset, repeating indices as needed.
+	DuplicatesKeep DuplicatePolicy = iota
+	// DuplicatesDedupe keeps only the first occurrence of each index.
+	DuplicatesDedupe
+	// DuplicatesError fails the lookup if any index repeats.
+	DuplicatesError
+)
+
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case DuplicatesKeep:
+		return "keep"
+	case DuplicatesDedupe:
+		return "dedupe"
+	case DuplicatesError:
+		return "error"
+	default:
+		return fmt.Sprintf("DuplicatePolicy(%d)", int(p))
+	}
+}
+
+// OffGridPolicy decides how neighbors that fall off a non-wrapping edge are
+// reported.
+type OffGridPolicy int
+
+const (
+	// OffGridOmit leaves off-grid neighbors out of the result.
+	OffGridOmit OffGridPolicy = iota
+	// OffGridSentinel reports off-grid neighbors as OffGridIndex in place.
+	OffGridSentinel
+)
+
+func (p OffGridPolicy) String() string {
+	switch p {
+	case OffGridOmit:
+		return "omit"
+	case OffGridSentinel:
+		return "sentinel"
+	default:
+		return fmt.Sprintf("OffGridPolicy(%d)", int(p))
+	}
+}
+
+type NeighborFinderConfig struct {
+	Stencil    Stencil
+	Duplicates DuplicatePolicy
+	OffGrid    OffGridPolicy
+}
+
 type NeighborFinder struct {
-	matrix *TorusMatrix
+	matrix     *TorusMatrix
+	stencil    Stencil
+	duplicates DuplicatePolicy
+	offGrid    OffGridPolicy
 }
 
 func NewNeighborFinder(matrix *TorusMatrix) *NeighborFinder {
 	return &NeighborFinder{
-		matrix: matrix,
+		matrix:     matrix,
+		stencil:    AllDirections,
+		duplicates: DuplicatesKeep,
+	}
+}
+
+// NewNeighborFinderWithConfig builds a finder for an arbitrary stencil. An
+// empty stencil defaults to AllDirections.
+func NewNeighborFinderWithConfig(matrix *TorusMatrix, config NeighborFinderConfig) (*NeighborFinder, error) {
+	stencil := config.Stencil
+	if len(stencil) == 0 {
+		stencil = AllDirections
+	}
+
+	switch config.Duplicates {
+	case DuplicatesKeep, DuplicatesDedupe, DuplicatesError:
+	default:
+		return nil, fmt.Errorf("unknown duplicate policy %s", config.Duplicates)
 	}
+
+	switch config.OffGrid {
+	case OffGridOmit, OffGridSentinel:
+	default:
+		return nil, fmt.Errorf("unknown off-grid policy %s", config.OffGrid)
+	}
+
+	return &NeighborFinder{
+		matrix:     matrix,
+		stencil:    stencil,
+		duplicates: config.Duplicates,
+		offGrid:    config.OffGrid,
+	}, nil
+}
+
+func (nf *NeighborFinder) Stencil() Stencil {
+	return append(Stencil(nil), nf.stencil...)
 }
 
 func (nf *NeighborFinder) FindNeighbors(index int) ([]int, error) {
@@ -40,13 +131,35 @@ func (nf *NeighborFinder) FindNeighbors(index int) ([]int, error) {
 		return nil, fmt.Errorf("failed to convert index to coordinates: %w", err)
 	}
 
-	neighbors := make([]int, 0, len(AllDirections))
+	neighbors := make([]int, 0, len(nf.stencil))
+	var seen map[int]string
+	if nf.duplicates != DuplicatesKeep {
+		seen = make(map[int]string, len(nf.stencil))
+	}
 
-	for _, direction := range AllDirections {
+	for _, direction := range nf.stencil {
 		neighborRow := centerRow + direction.RowOffset
 		neighborCol := centerCol + direction.ColOffset
 
-		neighborIndex := nf.matrix.CoordinatesToIndex(neighborRow, neighborCol)
+		neighborIndex, ok := nf.matrix.ResolveCoordinates(neighborRow, neighborCol)
+		if !ok {
+			if nf.offGrid == OffGridSentinel {
+				neighbors = append(neighbors, OffGridIndex)
+			}
+			continue
+		}
+
+		if nf.duplicates != DuplicatesKeep {
+			if firstName, ok := seen[neighborIndex]; ok {
+				if nf.duplicates == DuplicatesError {
+					return nil, fmt.Errorf("neighbors %s and %s of index %d both resolve to index %d",
+						firstName, direction.Name, index, neighborIndex)
+				}
+				continue
+			}
+			seen[neighborIndex] = direction.Name
+		}
+
 		neighbors = append(neighbors, neighborIndex)
 	}
 
@@ -116,3 +116,83 @@ func TestAllDirections(t *testing.T) {
 		t.Errorf("AllDirections doesn't match expected order and values")
 	}
 }
+
+func TestFindNeighborsWithStencil(t *testing.T) {
+	matrix, _ := NewTorusMatrix(5, 5)
+	moore2, _ := MooreStencil(2)
+
+	tests := []struct {
+		name     string
+		stencil  Stencil
+		index    int
+		expected []int
+	}{
+		{"von Neumann", VonNeumannStencil(), 12, []int{7, 11, 13, 17}},
+		{"von Neumann wrapping", VonNeumannStencil(), 0, []int{20, 4, 1, 5}},
+		{"Moore radius 2", moore2, 12, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finder, err := NewNeighborFinderWithConfig(matrix, NeighborFinderConfig{Stencil: tt.stencil})
+			if err != nil {
+				t.Fatalf("Failed to create finder: %v", err)
+			}
+
+			neighbors, err := finder.FindNeighbors(tt.index)
+			if err != nil {
+				t.Fatalf("FindNeighbors failed: %v", err)
+			}
+			if !reflect.DeepEqual(neighbors, tt.expected) {
+				t.Errorf("Expected neighbors %v, got %v", tt.expected, neighbors)
+			}
+		})
+	}
+}
+
+func TestFindNeighborsDuplicatePolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		width       int
+		height      int
+		index       int
+		policy      DuplicatePolicy
+		expected    []int
+		expectError bool
+	}{
+		{"1x3 keep", 3, 1, 2, DuplicatesKeep, []int{1, 2, 0, 1, 0, 1, 2, 0}, false},
+		{"1x3 dedupe", 3, 1, 2, DuplicatesDedupe, []int{1, 2, 0}, false},
+		{"1x3 error", 3, 1, 2, DuplicatesError, nil, true},
+		{"1x1 dedupe", 1, 1, 0, DuplicatesDedupe, []int{0}, false},
+		{"4x4 error without duplicates", 4, 4, 5, DuplicatesError, []int{0, 1, 2, 4, 6, 8, 9, 10}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matrix, _ := NewTorusMatrix(tt.width, tt.height)
+			finder, err := NewNeighborFinderWithConfig(matrix, NeighborFinderConfig{Duplicates: tt.policy})
+			if err != nil {
+				t.Fatalf("Failed to create finder: %v", err)
+			}
+
+			neighbors, err := finder.FindNeighbors(tt.index)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected duplicate error, got %v", neighbors)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindNeighbors failed: %v", err)
+			}
+			if !reflect.DeepEqual(neighbors, tt.expected) {
+				t.Errorf("Expected neighbors %v, got %v", tt.expected, neighbors)
+			}
+		})
+	}
+
+	matrix, _ := NewTorusMatrix(4, 4)
+	if _, err := NewNeighborFinderWithConfig(matrix, NeighborFinderConfig{Duplicates: DuplicatePolicy(42)}); err == nil {
+		t.Error("Expected error for unknown duplicate policy")
+	}
+}
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Stencil is an ordered list of neighbor offsets. Neighbors are always
+// reported in stencil order.
+type Stencil []NeighborDirection
+
+// MooreStencil returns every offset with Chebyshev distance 1..radius in
+// row-major order (top row first, left to right). Radius 1 equals AllDirections.
+func MooreStencil(radius int) (Stencil, error) {
+	if radius <= 0 {
+		return nil, fmt.Errorf("moore radius must be a positive integer, got %d", radius)
+	}
+
+	stencil := make(Stencil, 0, (2*radius+1)*(2*radius+1)-1)
+	for dr := -radius; dr <= radius; dr++ {
+		for dc := -radius; dc <= radius; dc++ {
+			if dr == 0 && dc == 0 {
+				continue
+			}
+			stencil = append(stencil, NeighborDirection{dr, dc, directionName(dr, dc)})
+		}
+	}
+	return stencil, nil
+}
+
+// VonNeumannStencil returns the four orthogonal neighbors in row-major order:
+// Top, Left, Right, Bottom.
+func VonNeumannStencil() Stencil {
+	stencil, _ := ExtendedVonNeumannStencil(1)
+	return stencil
+}
+
+// ExtendedVonNeumannStencil returns every offset with Manhattan distance
+// 1..radius in row-major order.
+func ExtendedVonNeumannStencil(radius int) (Stencil, error) {
+	if radius <= 0 {
+		return nil, fmt.Errorf("von Neumann radius must be a positive integer, got %d", radius)
+	}
+
+	var stencil Stencil
+	for dr := -radius; dr <= radius; dr++ {
+		for dc := -radius; dc <= radius; dc++ {
+			if dr == 0 && dc == 0 || abs(dr)+abs(dc) > radius {
+				continue
+			}
+			stencil = append(stencil, NeighborDirection{dr, dc, directionName(dr, dc)})
+		}
+	}
+	return stencil, nil
+}
+
+// CustomStencil validates a caller supplied offset list and keeps its order.
+// Unnamed directions get a generated name.
+func CustomStencil(directions []NeighborDirection) (Stencil, error) {
+	if len(directions) == 0 {
+		return nil, fmt.Errorf("stencil must contain at least one offset")
+	}
+
+	seen := make(map[[2]int]int, len(directions))
+	stencil := make(Stencil, len(directions))
+	for i, direction := range directions {
+		key := [2]int{direction.RowOffset, direction.ColOffset}
+		if first, ok := seen[key]; ok {
+			return nil, fmt.Errorf("offset (%d,%d) at position %d duplicates position %d",
+				direction.RowOffset, direction.ColOffset, i, first)
+		}
+		seen[key] = i
+
+		if direction.Name == "" {
+			direction.Name = directionName(direction.RowOffset, direction.ColOffset)
+		}
+		stencil[i] = direction
+	}
+	return stencil, nil
+}
+
+// ParseStencil reads one offset per line as "row,col" or "row,col,name".
+// Blank lines and lines starting with '#' are ignored.
+func ParseStencil(r io.Reader) (Stencil, error) {
+	var directions []NeighborDirection
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("line %d: expected 'row,col[,name]', got %q", lineNumber, line)
+		}
+
+		rowOffset, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid row offset: %w", lineNumber, err)
+		}
+		colOffset, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid column offset: %w", lineNumber, err)
+		}
+
+		direction := NeighborDirection{RowOffset: rowOffset, ColOffset: colOffset}
+		if len(fields) == 3 {
+			direction.Name = strings.TrimSpace(fields[2])
+		}
+		directions = append(directions, direction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stencil: %w", err)
+	}
+
+	return CustomStencil(directions)
+}
+
+func LoadStencil(path string) (Stencil, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stencil file: %w", err)
+	}
+	defer file.Close()
+
+	stencil, err := ParseStencil(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse stencil file %s: %w", path, err)
+	}
+	return stencil, nil
+}
+
+func directionName(rowOffset, colOffset int) string {
+	for _, direction := range AllDirections {
+		if direction.RowOffset == rowOffset && direction.ColOffset == colOffset {
+			return direction.Name
+		}
+	}
+	return fmt.Sprintf("Offset(%d,%d)", rowOffset, colOffset)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func stencilOffsets(stencil Stencil) [][2]int {
+	offsets := make([][2]int, len(stencil))
+	for i, direction := range stencil {
+		offsets[i] = [2]int{direction.RowOffset, direction.ColOffset}
+	}
+	return offsets
+}
+
+func TestMooreStencil(t *testing.T) {
+	radiusOne, err := MooreStencil(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(radiusOne, Stencil(AllDirections)) {
+		t.Errorf("Radius 1 Moore stencil should equal AllDirections, got %v", radiusOne)
+	}
+
+	radiusTwo, err := MooreStencil(2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(radiusTwo) != 24 {
+		t.Errorf("Expected 24 offsets for radius 2, got %d", len(radiusTwo))
+	}
+	if first := radiusTwo[0]; first.RowOffset != -2 || first.ColOffset != -2 {
+		t.Errorf("Expected first offset (-2,-2), got (%d,%d)", first.RowOffset, first.ColOffset)
+	}
+
+	if _, err := MooreStencil(0); err == nil {
+		t.Error("Expected error for zero radius")
+	}
+}
+
+func TestVonNeumannStencil(t *testing.T) {
+	expected := [][2]int{{-1, 0}, {0, -1}, {0, 1}, {1, 0}}
+	if actual := stencilOffsets(VonNeumannStencil()); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	extended, err := ExtendedVonNeumannStencil(2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectedExtended := [][2]int{
+		{-2, 0},
+		{-1, -1}, {-1, 0}, {-1, 1},
+		{0, -2}, {0, -1}, {0, 1}, {0, 2},
+		{1, -1}, {1, 0}, {1, 1},
+		{2, 0},
+	}
+	if actual := stencilOffsets(extended); !reflect.DeepEqual(actual, expectedExtended) {
+		t.Errorf("Expected %v, got %v", expectedExtended, actual)
+	}
+
+	if _, err := ExtendedVonNeumannStencil(-1); err == nil {
+		t.Error("Expected error for negative radius")
+	}
+}
+
+func TestCustomStencil(t *testing.T) {
+	stencil, err := CustomStencil([]NeighborDirection{{0, 2, ""}, {0, 0, "Self"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stencil[0].Name != "Offset(0,2)" || stencil[1].Name != "Self" {
+		t.Errorf("Unexpected names: %q, %q", stencil[0].Name, stencil[1].Name)
+	}
+
+	if _, err := CustomStencil(nil); err == nil {
+		t.Error("Expected error for empty stencil")
+	}
+	if _, err := CustomStencil([]NeighborDirection{{1, 0, "A"}, {1, 0, "B"}}); err == nil {
+		t.Error("Expected error for duplicate offsets")
+	}
+}
+
+func TestParseStencil(t *testing.T) {
+	input := `
+# knight moves
+-2,-1,KnightA
+-2, 1
+ 1,2
+`
+	stencil, err := ParseStencil(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := [][2]int{{-2, -1}, {-2, 1}, {1, 2}}
+	if actual := stencilOffsets(stencil); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+	if stencil[0].Name != "KnightA" {
+		t.Errorf("Expected name KnightA, got %q", stencil[0].Name)
+	}
+
+	invalid := []string{"1", "a,1", "1,b", "1,2,3,4", "# only a comment"}
+	for _, in := range invalid {
+		if _, err := ParseStencil(strings.NewReader(in)); err == nil {
+			t.Errorf("Expected error for input %q", in)
+		}
+	}
+}
+
+func TestLoadStencil(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stencil.txt")
+	if err := os.WriteFile(path, []byte("-1,0\n1,0\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write stencil file: %v", err)
+	}
+
+	stencil, err := LoadStencil(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(stencil) != 2 || stencil[0].Name != "Top" || stencil[1].Name != "Bottom" {
+		t.Errorf("Unexpected stencil: %v", stencil)
+	}
+
+	if _, err := LoadStencil(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
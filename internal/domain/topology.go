@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OffGridIndex is reported for coordinates that have no image on the grid,
+// e.g. cells beyond the edge of a bounded grid.
+const OffGridIndex = -1
+
+// Topology decides how coordinates outside the width x height grid are glued
+// back onto it. Resolve returns ok=false when the point falls off the surface.
+type Topology interface {
+	Name() string
+	Resolve(row, col, width, height int) (wrappedRow, wrappedCol int, ok bool)
+}
+
+// TorusTopology wraps both axes without any flip.
+type TorusTopology struct{}
+
+func (TorusTopology) Name() string { return "torus" }
+
+func (TorusTopology) Resolve(row, col, width, height int) (int, int, bool) {
+	return wrap(row, height), wrap(col, width), true
+}
+
+// TwistedTorusTopology is a helical torus. Every crossing of the top or bottom
+// edge shifts the column by ColShift and every crossing of the left or right
+// edge shifts the row by RowShift. Vertical crossings are resolved first, so
+// when both shifts are set the row shift is applied to the already shifted
+// column. Zero shifts behave exactly like TorusTopology.
+type TwistedTorusTopology struct {
+	ColShift int
+	RowShift int
+}
+
+func (TwistedTorusTopology) Name() string { return "twisted-torus" }
+
+func (t TwistedTorusTopology) Resolve(row, col, width, height int) (int, int, bool) {
+	col += floorDiv(row, height) * t.ColShift
+	row += floorDiv(col, width) * t.RowShift
+	return wrap(row, height), wrap(col, width), true
+}
+
+// CylinderTopology wraps one axis and bounds the other. By default the left
+// and right edges are joined; WrapRows joins the top and bottom edges instead.
+type CylinderTopology struct {
+	WrapRows bool
+}
+
+func (c CylinderTopology) Name() string {
+	if c.WrapRows {
+		return "cylinder-rows"
+	}
+	return "cylinder"
+}
+
+func (c CylinderTopology) Resolve(row, col, width, height int) (int, int, bool) {
+	if c.WrapRows {
+		return wrap(row, height), col, col >= 0 && col < width
+	}
+	return row, wrap(col, width), row >= 0 && row < height
+}
+
+// MobiusStripTopology joins the left and right edges with a vertical flip;
+// the top and bottom edges are boundaries.
+type MobiusStripTopology struct{}
+
+func (MobiusStripTopology) Name() string { return "mobius" }
+
+func (MobiusStripTopology) Resolve(row, col, width, height int) (int, int, bool) {
+	if floorDiv(col, width)%2 != 0 {
+		row = height - 1 - row
+	}
+	return row, wrap(col, width), row >= 0 && row < height
+}
+
+// KleinBottleTopology joins the left and right edges with a vertical flip and
+// the top and bottom edges plainly.
+type KleinBottleTopology struct{}
+
+func (KleinBottleTopology) Name() string { return "klein" }
+
+func (KleinBottleTopology) Resolve(row, col, width, height int) (int, int, bool) {
+	wrappedRow := wrap(row, height)
+	if floorDiv(col, width)%2 != 0 {
+		wrappedRow = height - 1 - wrappedRow
+	}
+	return wrappedRow, wrap(col, width), true
+}
+
+// ProjectivePlaneTopology joins both pairs of opposite edges with a flip:
+// crossing a vertical edge mirrors the row, crossing a horizontal edge
+// mirrors the column.
+type ProjectivePlaneTopology struct{}
+
+func (ProjectivePlaneTopology) Name() string { return "projective" }
+
+func (ProjectivePlaneTopology) Resolve(row, col, width, height int) (int, int, bool) {
+	wrappedRow, wrappedCol := wrap(row, height), wrap(col, width)
+	if floorDiv(col, width)%2 != 0 {
+		wrappedRow = height - 1 - wrappedRow
+	}
+	if floorDiv(row, height)%2 != 0 {
+		wrappedCol = width - 1 - wrappedCol
+	}
+	return wrappedRow, wrappedCol, true
+}
+
+// BoundedTopology is an ordinary grid without any wrapping.
+type BoundedTopology struct{}
+
+func (BoundedTopology) Name() string { return "bounded" }
+
+func (BoundedTopology) Resolve(row, col, width, height int) (int, int, bool) {
+	return row, col, row >= 0 && row < height && col >= 0 && col < width
+}
+
+var topologies = []Topology{
+	TorusTopology{},
+	CylinderTopology{},
+	CylinderTopology{WrapRows: true},
+	MobiusStripTopology{},
+	KleinBottleTopology{},
+	ProjectivePlaneTopology{},
+	BoundedTopology{},
+}
+
+func ParseTopology(name string) (Topology, error) {
+	names := make([]string, len(topologies))
+	for i, topology := range topologies {
+		if strings.EqualFold(topology.Name(), name) {
+			return topology, nil
+		}
+		names[i] = topology.Name()
+	}
+	return nil, fmt.Errorf("unknown topology %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+func wrap(value, size int) int {
+	wrapped := value % size
+	if wrapped < 0 {
+		wrapped += size
+	}
+	return wrapped
+}
+
+func floorDiv(value, size int) int {
+	quotient := value / size
+	if value%size < 0 {
+		quotient--
+	}
+	return quotient
+}
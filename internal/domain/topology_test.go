@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopologyResolve(t *testing.T) {
+	const width, height = 3, 3
+
+	tests := []struct {
+		name     string
+		topology Topology
+		row      int
+		col      int
+		expected int
+	}{
+		{"torus inside", TorusTopology{}, 1, 1, 4},
+		{"torus wraps left", TorusTopology{}, 0, -1, 2},
+		{"torus wraps top", TorusTopology{}, -1, 0, 6},
+		{"cylinder wraps columns", CylinderTopology{}, 1, 3, 3},
+		{"cylinder bounds rows", CylinderTopology{}, -1, 0, OffGridIndex},
+		{"row cylinder wraps rows", CylinderTopology{WrapRows: true}, 3, 1, 1},
+		{"row cylinder bounds columns", CylinderTopology{WrapRows: true}, 0, -1, OffGridIndex},
+		{"mobius flips across columns", MobiusStripTopology{}, 0, 3, 6},
+		{"mobius flips left edge", MobiusStripTopology{}, 0, -1, 8},
+		{"mobius double crossing", MobiusStripTopology{}, 0, 6, 0},
+		{"mobius bounds rows", MobiusStripTopology{}, 3, 1, OffGridIndex},
+		{"klein flips across columns", KleinBottleTopology{}, 0, 3, 6},
+		{"klein wraps rows plainly", KleinBottleTopology{}, -1, 0, 6},
+		{"klein corner", KleinBottleTopology{}, -1, -1, 2},
+		{"projective flips across columns", ProjectivePlaneTopology{}, 0, 3, 6},
+		{"projective flips across rows", ProjectivePlaneTopology{}, -1, 0, 8},
+		{"projective corner", ProjectivePlaneTopology{}, -1, -1, 0},
+		{"bounded inside", BoundedTopology{}, 2, 2, 8},
+		{"bounded outside", BoundedTopology{}, 3, 0, OffGridIndex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matrix, err := NewTorusMatrixWithConfig(width, height, MatrixConfig{Topology: tt.topology})
+			if err != nil {
+				t.Fatalf("Failed to create matrix: %v", err)
+			}
+
+			actual := matrix.CoordinatesToIndex(tt.row, tt.col)
+			if actual != tt.expected {
+				t.Errorf("For (%d,%d) expected %d, got %d", tt.row, tt.col, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseTopology(t *testing.T) {
+	for _, name := range []string{"torus", "cylinder", "cylinder-rows", "mobius", "klein", "projective", "bounded"} {
+		topology, err := ParseTopology(name)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", name, err)
+			continue
+		}
+		if topology.Name() != name {
+			t.Errorf("Expected topology %q, got %q", name, topology.Name())
+		}
+	}
+
+	if _, err := ParseTopology("sphere"); err == nil {
+		t.Error("Expected error for unknown topology")
+	}
+}
+
+func TestWrappedMatrixMobius(t *testing.T) {
+	matrix, _ := NewTorusMatrixWithConfig(3, 3, MatrixConfig{Topology: MobiusStripTopology{}})
+	hasher := NewMatrixHasher(matrix)
+
+	expected := [][]int{
+		{-1, -1, -1, -1, -1},
+		{8, 0, 1, 2, 6},
+		{5, 3, 4, 5, 3},
+		{2, 6, 7, 8, 0},
+		{-1, -1, -1, -1, -1},
+	}
+
+	if wrapped := mustGenerateWrappedMatrix(t, hasher); !reflect.DeepEqual(wrapped, expected) {
+		t.Errorf("Expected %v, got %v", expected, wrapped)
+	}
+}
+
+func TestTorusTopologyKeepsHash(t *testing.T) {
+	matrix, _ := NewTorusMatrixWithConfig(4, 4, MatrixConfig{Topology: TorusTopology{}})
+	hasher := NewMatrixHasher(matrix)
+
+	if err := hasher.ValidateExpectedHash("hJVz5fi5z2YecMNLsihGJQHBpAGUAYitNUOFGmjBg38="); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFindNeighborsBoundedGrid(t *testing.T) {
+	matrix, _ := NewTorusMatrixWithConfig(3, 3, MatrixConfig{Topology: BoundedTopology{}})
+
+	tests := []struct {
+		name     string
+		policy   OffGridPolicy
+		expected []int
+	}{
+		{"omit", OffGridOmit, []int{1, 3, 4}},
+		{"sentinel", OffGridSentinel, []int{-1, -1, -1, -1, 1, -1, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finder, err := NewNeighborFinderWithConfig(matrix, NeighborFinderConfig{OffGrid: tt.policy})
+			if err != nil {
+				t.Fatalf("Failed to create finder: %v", err)
+			}
+
+			neighbors, err := finder.FindNeighbors(0)
+			if err != nil {
+				t.Fatalf("FindNeighbors failed: %v", err)
+			}
+			if !reflect.DeepEqual(neighbors, tt.expected) {
+				t.Errorf("Expected neighbors %v, got %v", tt.expected, neighbors)
+			}
+		})
+	}
+
+	if _, err := NewNeighborFinderWithConfig(matrix, NeighborFinderConfig{OffGrid: OffGridPolicy(7)}); err == nil {
+		t.Error("Expected error for unknown off-grid policy")
+	}
+}
+
+func TestFindNeighborsSentinelsIgnoreDuplicatePolicy(t *testing.T) {
+	matrix, _ := NewTorusMatrixWithConfig(1, 1, MatrixConfig{Topology: BoundedTopology{}})
+	finder, _ := NewNeighborFinderWithConfig(matrix, NeighborFinderConfig{
+		Duplicates: DuplicatesError,
+		OffGrid:    OffGridSentinel,
+	})
+
+	neighbors, err := finder.FindNeighbors(0)
+	if err != nil {
+		t.Fatalf("FindNeighbors failed: %v", err)
+	}
+	if len(neighbors) != 8 {
+		t.Errorf("Expected 8 sentinels, got %v", neighbors)
+	}
+}
+
+func TestTwistedTorusNeighbors(t *testing.T) {
+	tests := []struct {
+		name     string
+		colShift int
+		rowShift int
+		index    int
+		expected []int
+	}{
+		{"zero twist matches torus", 0, 0, 0, []int{15, 12, 13, 3, 1, 7, 4, 5}},
+		{"column shift top-left", 1, 0, 0, []int{14, 15, 12, 3, 1, 7, 4, 5}},
+		{"column shift bottom-right", 1, 0, 15, []int{10, 11, 8, 14, 12, 3, 0, 1}},
+		{"row shift top-left", 0, 1, 0, []int{11, 12, 13, 15, 1, 3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matrix, err := NewTwistedTorusMatrix(4, 4, tt.colShift, tt.rowShift)
+			if err != nil {
+				t.Fatalf("Failed to create matrix: %v", err)
+			}
+
+			neighbors, err := NewNeighborFinder(matrix).FindNeighbors(tt.index)
+			if err != nil {
+				t.Fatalf("FindNeighbors failed: %v", err)
+			}
+			if !reflect.DeepEqual(neighbors, tt.expected) {
+				t.Errorf("Expected neighbors %v, got %v", tt.expected, neighbors)
+			}
+		})
+	}
+}
+
+func TestTwistedTorusWrappedMatrix(t *testing.T) {
+	zero, _ := NewTwistedTorusMatrix(4, 4, 0, 0)
+	if err := NewMatrixHasher(zero).ValidateExpectedHash("hJVz5fi5z2YecMNLsihGJQHBpAGUAYitNUOFGmjBg38="); err != nil {
+		t.Errorf("Zero twist should keep the torus hash: %v", err)
+	}
+
+	twisted, _ := NewTwistedTorusMatrix(3, 2, 1, 0)
+	expected := [][]int{
+		{4, 5, 3, 4, 5},
+		{2, 0, 1, 2, 0},
+		{5, 3, 4, 5, 3},
+		{0, 1, 2, 0, 1},
+	}
+	if wrapped := mustGenerateWrappedMatrix(t, NewMatrixHasher(twisted)); !reflect.DeepEqual(wrapped, expected) {
+		t.Errorf("Expected %v, got %v", expected, wrapped)
+	}
+}
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TorusND is a periodic lattice with an arbitrary number of axes. Shape is
+// ordered from the slowest to the fastest varying axis, so a 2D torus of
+// shape {height, width} numbers its cells exactly like TorusMatrix.
+type TorusND struct {
+	shape []int
+	total int
+}
+
+func NewTorusND(shape ...int) (*TorusND, error) {
+	if len(shape) == 0 {
+		return nil, fmt.Errorf("torus must have at least one dimension")
+	}
+
+	total := 1
+	for axis, size := range shape {
+		if size <= 0 {
+			return nil, fmt.Errorf("dimension sizes must be positive integers, got %d on axis %d", size, axis)
+		}
+		if size > math.MaxInt/total {
+			return nil, fmt.Errorf("torus of shape %v has more elements than fit in an int", shape)
+		}
+		total *= size
+	}
+
+	return &TorusND{
+		shape: append([]int(nil), shape...),
+		total: total,
+	}, nil
+}
+
+func (t *TorusND) Shape() []int {
+	return append([]int(nil), t.shape...)
+}
+
+func (t *TorusND) Rank() int {
+	return len(t.shape)
+}
+
+func (t *TorusND) TotalElements() int {
+	return t.total
+}
+
+func (t *TorusND) IsValidIndex(index int) bool {
+	return index >= 0 && index < t.total
+}
+
+func (t *TorusND) IndexToCoordinates(index int) ([]int, error) {
+	if !t.IsValidIndex(index) {
+		return nil, fmt.Errorf("index %d is out of bounds for torus %s", index, t)
+	}
+
+	coords := make([]int, len(t.shape))
+	for axis := len(t.shape) - 1; axis >= 0; axis-- {
+		coords[axis] = index % t.shape[axis]
+		index /= t.shape[axis]
+	}
+	return coords, nil
+}
+
+func (t *TorusND) CoordinatesToIndex(coords []int) (int, error) {
+	if len(coords) != len(t.shape) {
+		return 0, fmt.Errorf("expected %d coordinates, got %d", len(t.shape), len(coords))
+	}
+
+	index := 0
+	for axis, coord := range coords {
+		size := t.shape[axis]
+		wrapped := ((coord % size) + size) % size
+		index = index*size + wrapped
+	}
+	return index, nil
+}
+
+func (t *TorusND) String() string {
+	parts := make([]string, len(t.shape))
+	for i, size := range t.shape {
+		parts[i] = fmt.Sprint(size)
+	}
+	return strings.Join(parts, "x")
+}
+
+// MooreOffsets returns the 3^d-1 offset vectors of the Moore neighborhood in
+// lexicographic order, first axis most significant, skipping the origin.
+// For d=2 this is the same order as AllDirections.
+func MooreOffsets(dimensions int) [][]int {
+	if dimensions <= 0 {
+		return nil
+	}
+
+	count := 1
+	for i := 0; i < dimensions; i++ {
+		count *= 3
+	}
+
+	offsets := make([][]int, 0, count-1)
+	for n := 0; n < count; n++ {
+		offset := make([]int, dimensions)
+		rest := n
+		isOrigin := true
+		for axis := dimensions - 1; axis >= 0; axis-- {
+			offset[axis] = rest%3 - 1
+			rest /= 3
+			if offset[axis] != 0 {
+				isOrigin = false
+			}
+		}
+		if !isOrigin {
+			offsets = append(offsets, offset)
+		}
+	}
+	return offsets
+}
+
+type NDNeighborFinder struct {
+	torus   *TorusND
+	offsets [][]int
+}
+
+func NewNDNeighborFinder(torus *TorusND) *NDNeighborFinder {
+	return &NDNeighborFinder{
+		torus:   torus,
+		offsets: MooreOffsets(torus.Rank()),
+	}
+}
+
+// FindNeighbors returns the Moore neighbors of index in MooreOffsets order.
+func (nf *NDNeighborFinder) FindNeighbors(index int) ([]int, error) {
+	center, err := nf.torus.IndexToCoordinates(index)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index %d: %w", index, err)
+	}
+
+	neighbors := make([]int, 0, len(nf.offsets))
+	coords := make([]int, len(center))
+
+	for _, offset := range nf.offsets {
+		for axis := range center {
+			coords[axis] = center[axis] + offset[axis]
+		}
+
+		neighborIndex, err := nf.torus.CoordinatesToIndex(coords)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert coordinates to index: %w", err)
+		}
+		neighbors = append(neighbors, neighborIndex)
+	}
+
+	return neighbors, nil
+}
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNewTorusND(t *testing.T) {
+	tests := []struct {
+		name        string
+		shape       []int
+		expectError bool
+	}{
+		{"Valid 1D torus", []int{5}, false},
+		{"Valid 3D torus", []int{2, 3, 4}, false},
+		{"Valid 4D torus", []int{2, 2, 2, 2}, false},
+		{"Invalid empty shape", []int{}, true},
+		{"Invalid zero axis", []int{3, 0, 3}, true},
+		{"Invalid negative axis", []int{-1, 3}, true},
+		{"Largest single axis", []int{math.MaxInt, 1}, false},
+		{"Overflow to negative", []int{math.MaxInt/2 + 1, 2}, true},
+		{"Overflow to zero", []int{1 << 16, 1 << 16, 1 << 16, 1 << 16}, true},
+		{"Overflow on a later axis", []int{2, 3, math.MaxInt / 4}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			torus, err := NewTorusND(tt.shape...)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for shape %v", tt.shape)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(torus.Shape(), tt.shape) {
+				t.Errorf("Expected shape %v, got %v", tt.shape, torus.Shape())
+			}
+		})
+	}
+}
+
+func TestTorusNDCoordinateRoundTrip(t *testing.T) {
+	torus, err := NewTorusND(2, 3, 4)
+	if err != nil {
+		t.Fatalf("Failed to create torus: %v", err)
+	}
+
+	if torus.TotalElements() != 24 {
+		t.Fatalf("Expected 24 elements, got %d", torus.TotalElements())
+	}
+
+	for index := 0; index < torus.TotalElements(); index++ {
+		coords, err := torus.IndexToCoordinates(index)
+		if err != nil {
+			t.Fatalf("Unexpected error for index %d: %v", index, err)
+		}
+
+		back, err := torus.CoordinatesToIndex(coords)
+		if err != nil {
+			t.Fatalf("Unexpected error for coordinates %v: %v", coords, err)
+		}
+		if back != index {
+			t.Errorf("Round trip of index %d via %v returned %d", index, coords, back)
+		}
+	}
+
+	if _, err := torus.IndexToCoordinates(24); err == nil {
+		t.Error("Expected error for out of bounds index")
+	}
+	if _, err := torus.CoordinatesToIndex([]int{0, 0}); err == nil {
+		t.Error("Expected error for wrong number of coordinates")
+	}
+}
+
+func TestTorusNDCoordinatesToIndexWrapping(t *testing.T) {
+	torus, _ := NewTorusND(2, 3, 4)
+
+	tests := []struct {
+		coords   []int
+		expected int
+	}{
+		{[]int{0, 0, 0}, 0},
+		{[]int{1, 2, 3}, 23},
+		{[]int{-1, 0, 0}, 12},
+		{[]int{0, -1, 0}, 8},
+		{[]int{0, 0, -1}, 3},
+		{[]int{2, 3, 4}, 0},
+		{[]int{-1, -1, -1}, 23},
+	}
+
+	for _, tt := range tests {
+		actual, err := torus.CoordinatesToIndex(tt.coords)
+		if err != nil {
+			t.Fatalf("Unexpected error for %v: %v", tt.coords, err)
+		}
+		if actual != tt.expected {
+			t.Errorf("For coordinates %v, expected index %d, got %d", tt.coords, tt.expected, actual)
+		}
+	}
+}
+
+func TestMooreOffsets(t *testing.T) {
+	tests := []struct {
+		dimensions int
+		expected   int
+	}{
+		{0, 0},
+		{1, 2},
+		{2, 8},
+		{3, 26},
+		{4, 80},
+	}
+
+	for _, tt := range tests {
+		offsets := MooreOffsets(tt.dimensions)
+		if len(offsets) != tt.expected {
+			t.Errorf("For %d dimensions, expected %d offsets, got %d", tt.dimensions, tt.expected, len(offsets))
+		}
+	}
+
+	expected2D := make([][]int, len(AllDirections))
+	for i, direction := range AllDirections {
+		expected2D[i] = []int{direction.RowOffset, direction.ColOffset}
+	}
+	if !reflect.DeepEqual(MooreOffsets(2), expected2D) {
+		t.Errorf("2D offsets %v do not match AllDirections order", MooreOffsets(2))
+	}
+}
+
+func TestNDNeighborFinderMatches2D(t *testing.T) {
+	shapes := [][2]int{{4, 4}, {4, 5}, {1, 3}, {1, 1}, {3, 7}}
+
+	for _, shape := range shapes {
+		height, width := shape[0], shape[1]
+
+		matrix, _ := NewTorusMatrix(width, height)
+		torus, _ := NewTorusND(height, width)
+
+		finder2D := NewNeighborFinder(matrix)
+		finderND := NewNDNeighborFinder(torus)
+
+		for index := 0; index < matrix.TotalElements(); index++ {
+			expected, _ := finder2D.FindNeighbors(index)
+			actual, err := finderND.FindNeighbors(index)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%dx%d index %d: expected %v, got %v", height, width, index, expected, actual)
+			}
+		}
+	}
+}
+
+func TestNDNeighborFinder3D(t *testing.T) {
+	torus, _ := NewTorusND(3, 3, 3)
+	finder := NewNDNeighborFinder(torus)
+
+	neighbors, err := finder.FindNeighbors(13)
+	if err != nil {
+		t.Fatalf("FindNeighbors failed: %v", err)
+	}
+
+	expected := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26}
+	if !reflect.DeepEqual(neighbors, expected) {
+		t.Errorf("Expected neighbors %v, got %v", expected, neighbors)
+	}
+
+	corner, err := finder.FindNeighbors(0)
+	if err != nil {
+		t.Fatalf("FindNeighbors failed: %v", err)
+	}
+	if len(corner) != 26 {
+		t.Fatalf("Expected 26 neighbors, got %d", len(corner))
+	}
+	if corner[0] != 26 || corner[25] != 13 {
+		t.Errorf("Unexpected wrapped corner neighbors: first=%d last=%d", corner[0], corner[25])
+	}
+
+	if _, err := finder.FindNeighbors(27); err == nil {
+		t.Error("Expected error for invalid index")
+	}
+}
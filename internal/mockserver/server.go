@@ -0,0 +1,283 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"torus-neighbors/internal/domain"
+)
+
+const (
+	defaultMaxWidth  = 10
+	defaultMaxHeight = 10
+)
+
+type Config struct {
+	// Seed makes the issued challenges reproducible; 0 seeds from the clock.
+	Seed int64
+	// MaxWidth and MaxHeight bound random challenges; 0 means 10.
+	MaxWidth  int
+	MaxHeight int
+	// Fixed, when set, is issued for every new UUID instead of a random one.
+	Fixed *Challenge
+	// ChallengeForms restricts how challenges may be requested: "post",
+	// "query" (GET with URL parameters) or "get-body" (GET with a JSON body).
+	// Other forms are refused with 405; submissions are not affected. Empty
+	// accepts all of them.
+	ChallengeForms []string
+}
+
+type Challenge struct {
+	Width       int
+	Height      int
+	TargetIndex int
+}
+
+type Grade struct {
+	Accepted bool
+	Result   string
+	Hash     string
+}
+
+type challengeRequest struct {
+	UUID   string  `json:"uuid"`
+	User   string  `json:"user"`
+	Result *string `json:"result"`
+	Hash   *string `json:"hash"`
+}
+
+type challengeResponse struct {
+	UUID string `json:"uuid"`
+	SetX string `json:"set_x"`
+	SetY string `json:"set_y"`
+	SetZ string `json:"set_z"`
+}
+
+type gradeResponse struct {
+	UUID     string `json:"uuid"`
+	Accepted bool   `json:"accepted"`
+	Message  string `json:"message"`
+}
+
+// Server is an in-process stand-in for the challenge API. It implements
+// GET /ping and /challenge-me-easy: a request carrying only uuid and user
+// issues a challenge, one carrying result and hash grades the answer. The
+// fields are read from the JSON body of a POST or GET, or from the URL
+// parameters of a GET without body.
+type Server struct {
+	mu         sync.Mutex
+	config     Config
+	rng        *rand.Rand
+	challenges map[string]Challenge
+	users      map[string]string
+	grades     map[string]Grade
+	mux        *http.ServeMux
+}
+
+func NewServer(config Config) *Server {
+	if config.MaxWidth <= 0 {
+		config.MaxWidth = defaultMaxWidth
+	}
+	if config.MaxHeight <= 0 {
+		config.MaxHeight = defaultMaxHeight
+	}
+
+	seed := uint64(config.Seed)
+	if config.Seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+
+	s := &Server{
+		config:     config,
+		rng:        rand.New(rand.NewPCG(seed, seed>>32)),
+		challenges: make(map[string]Challenge),
+		users:      make(map[string]string),
+		grades:     make(map[string]Grade),
+		mux:        http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/ping", s.handlePing)
+	s.mux.HandleFunc("/challenge-me-easy", s.handleChallenge)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Challenge returns the challenge issued for uuid.
+func (s *Server) Challenge(uuid string) (Challenge, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	challenge, ok := s.challenges[uuid]
+	return challenge, ok
+}
+
+// Grade returns the verdict of the latest submission for uuid.
+func (s *Server) Grade(uuid string) (Grade, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	grade, ok := s.grades[uuid]
+	return grade, ok
+}
+
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	io.WriteString(w, "pong")
+}
+
+func (s *Server) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request challengeRequest
+	form := "post"
+	if r.Method == http.MethodGet {
+		form = "get-body"
+	}
+	if query := r.URL.Query(); r.Method == http.MethodGet && query.Has("uuid") {
+		form = "query"
+		request.UUID, request.User = query.Get("uuid"), query.Get("user")
+		if query.Has("result") {
+			result := query.Get("result")
+			request.Result = &result
+		}
+		if query.Has("hash") {
+			hash := query.Get("hash")
+			request.Hash = &hash
+		}
+	}
+	if form != "query" {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("invalid JSON body: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if strings.TrimSpace(request.UUID) == "" {
+		http.Error(w, "uuid is required", http.StatusBadRequest)
+		return
+	}
+
+	if request.Result != nil || request.Hash != nil {
+		s.grade(w, request)
+		return
+	}
+	if len(s.config.ChallengeForms) > 0 && !slices.Contains(s.config.ChallengeForms, form) {
+		http.Error(w, fmt.Sprintf("challenge requests as %s are not supported", form), http.StatusMethodNotAllowed)
+		return
+	}
+	s.issue(w, request)
+}
+
+func (s *Server) issue(w http.ResponseWriter, request challengeRequest) {
+	s.mu.Lock()
+	challenge, ok := s.challenges[request.UUID]
+	if !ok {
+		challenge = s.newChallenge()
+		s.challenges[request.UUID] = challenge
+		s.users[request.UUID] = request.User
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, challengeResponse{
+		UUID: request.UUID,
+		SetX: strconv.Itoa(challenge.Width),
+		SetY: strconv.Itoa(challenge.Height),
+		SetZ: strconv.Itoa(challenge.TargetIndex),
+	})
+}
+
+func (s *Server) newChallenge() Challenge {
+	if s.config.Fixed != nil {
+		return *s.config.Fixed
+	}
+
+	width := 1 + s.rng.IntN(s.config.MaxWidth)
+	height := 1 + s.rng.IntN(s.config.MaxHeight)
+	return Challenge{
+		Width:       width,
+		Height:      height,
+		TargetIndex: s.rng.IntN(width * height),
+	}
+}
+
+func (s *Server) grade(w http.ResponseWriter, request challengeRequest) {
+	challenge, ok := s.Challenge(request.UUID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown uuid %s", request.UUID), http.StatusNotFound)
+		return
+	}
+	if request.Result == nil || request.Hash == nil {
+		http.Error(w, "both result and hash are required", http.StatusBadRequest)
+		return
+	}
+
+	expectedResult, expectedHash, err := Solve(challenge)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to grade challenge: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	grade := Grade{
+		Accepted: *request.Result == expectedResult && *request.Hash == expectedHash,
+		Result:   *request.Result,
+		Hash:     *request.Hash,
+	}
+
+	s.mu.Lock()
+	s.grades[request.UUID] = grade
+	s.mu.Unlock()
+
+	response := gradeResponse{UUID: request.UUID, Accepted: grade.Accepted, Message: "correct"}
+	switch {
+	case grade.Accepted:
+	case *request.Result != expectedResult:
+		response.Message = "wrong neighbors"
+	default:
+		response.Message = "wrong hash"
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Solve computes the expected result and hash for a challenge.
+func Solve(challenge Challenge) (result, hash string, err error) {
+	matrix, err := domain.NewTorusMatrix(challenge.Width, challenge.Height)
+	if err != nil {
+		return "", "", err
+	}
+
+	neighbors, err := domain.NewNeighborFinder(matrix).FindNeighbors(challenge.TargetIndex)
+	if err != nil {
+		return "", "", err
+	}
+
+	parts := make([]string, len(neighbors))
+	for i, neighbor := range neighbors {
+		parts[i] = strconv.Itoa(neighbor)
+	}
+
+	hash, err = domain.NewMatrixHasher(matrix).CalculateHash()
+	if err != nil {
+		return "", "", err
+	}
+	return strings.Join(parts, ","), hash, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
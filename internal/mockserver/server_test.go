@@ -0,0 +1,240 @@
+package mockserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"torus-neighbors/internal/api"
+	"torus-neighbors/internal/service"
+)
+
+func TestPing(t *testing.T) {
+	server := httptest.NewServer(NewServer(Config{Seed: 1}))
+	defer server.Close()
+
+	if err := api.NewClient(server.URL).Ping(); err != nil {
+		t.Errorf("Ping should succeed, got error: %v", err)
+	}
+}
+
+func TestIssueChallengeIsSeededAndStable(t *testing.T) {
+	first := NewServer(Config{Seed: 42})
+	second := NewServer(Config{Seed: 42})
+
+	serverA := httptest.NewServer(first)
+	defer serverA.Close()
+	serverB := httptest.NewServer(second)
+	defer serverB.Close()
+
+	a, err := api.NewClient(serverA.URL).GetChallenge("uuid-1", "user")
+	if err != nil {
+		t.Fatalf("GetChallenge failed: %v", err)
+	}
+	b, err := api.NewClient(serverB.URL).GetChallenge("uuid-1", "user")
+	if err != nil {
+		t.Fatalf("GetChallenge failed: %v", err)
+	}
+	if *a != *b {
+		t.Errorf("Same seed should issue the same challenge, got %+v and %+v", a, b)
+	}
+
+	again, _ := api.NewClient(serverA.URL).GetChallenge("uuid-1", "user")
+	if *again != *a {
+		t.Errorf("Repeated request for the same uuid should return %+v, got %+v", a, again)
+	}
+
+	challenge, ok := first.Challenge("uuid-1")
+	if !ok {
+		t.Fatal("Issued challenge should be remembered")
+	}
+	if a.SetX != strconv.Itoa(challenge.Width) || a.SetY != strconv.Itoa(challenge.Height) || a.SetZ != strconv.Itoa(challenge.TargetIndex) {
+		t.Errorf("Response %+v does not match remembered challenge %+v", a, challenge)
+	}
+	if challenge.Width < 1 || challenge.Width > defaultMaxWidth || challenge.TargetIndex >= challenge.Width*challenge.Height {
+		t.Errorf("Challenge out of range: %+v", challenge)
+	}
+}
+
+func TestGradeSubmission(t *testing.T) {
+	mock := NewServer(Config{Fixed: &Challenge{Width: 4, Height: 4, TargetIndex: 5}})
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	client := api.NewClient(server.URL)
+	if _, err := client.GetChallenge("uuid-1", "user"); err != nil {
+		t.Fatalf("GetChallenge failed: %v", err)
+	}
+
+	result, err := client.SubmitSolution("uuid-1", "0,1,2,4,6,8,9,10", "wrong")
+	if err != nil {
+		t.Fatalf("SubmitSolution failed: %v", err)
+	}
+	if grade, _ := mock.Grade("uuid-1"); grade.Accepted {
+		t.Error("Wrong hash should be rejected")
+	}
+	if result.Verdict != api.VerdictRejected || result.Message != "wrong hash" {
+		t.Errorf("Expected rejected verdict with message, got %+v", result)
+	}
+
+	result, err = client.SubmitSolution("uuid-1", "0,1,2,4,6,8,9,10", "hJVz5fi5z2YecMNLsihGJQHBpAGUAYitNUOFGmjBg38=")
+	if err != nil {
+		t.Fatalf("SubmitSolution failed: %v", err)
+	}
+	if grade, _ := mock.Grade("uuid-1"); !grade.Accepted {
+		t.Error("Correct answer should be accepted")
+	}
+	if !result.Accepted() {
+		t.Errorf("Expected accepted verdict, got %+v", result)
+	}
+
+	if _, err := client.SubmitSolution("unknown", "0", "x"); err == nil {
+		t.Error("Submission for unknown uuid should fail")
+	}
+}
+
+func TestMalformedRequests(t *testing.T) {
+	server := httptest.NewServer(NewServer(Config{Seed: 1}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"invalid JSON", http.MethodPost, "/challenge-me-easy", "{", http.StatusBadRequest},
+		{"missing uuid", http.MethodPost, "/challenge-me-easy", `{"user":"u"}`, http.StatusBadRequest},
+		{"unsupported method", http.MethodDelete, "/challenge-me-easy", "", http.StatusMethodNotAllowed},
+		{"ping with POST", http.MethodPost, "/ping", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestChallengeForms(t *testing.T) {
+	tests := []struct {
+		forms  []string
+		method api.ChallengeMethod
+	}{
+		{nil, api.ChallengeMethodPost},
+		{nil, api.ChallengeMethodQuery},
+		{nil, api.ChallengeMethodGetBody},
+		{[]string{"query"}, api.ChallengeMethodAuto},
+		{[]string{"get-body"}, api.ChallengeMethodAuto},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s accepting %v", tt.method, tt.forms), func(t *testing.T) {
+			mock := NewServer(Config{Seed: 3, ChallengeForms: tt.forms})
+			server := httptest.NewServer(mock)
+			defer server.Close()
+
+			client := api.NewClient(server.URL, api.WithChallengeMethod(tt.method))
+			response, err := client.GetChallenge("uuid-1", "user")
+			if err != nil {
+				t.Fatalf("GetChallenge failed: %v", err)
+			}
+			challenge, ok := mock.Challenge("uuid-1")
+			if !ok || response.SetX != strconv.Itoa(challenge.Width) {
+				t.Errorf("Response %+v does not match issued challenge %+v", response, challenge)
+			}
+		})
+	}
+
+	server := httptest.NewServer(NewServer(Config{Seed: 3, ChallengeForms: []string{"query"}}))
+	defer server.Close()
+	_, err := api.NewClient(server.URL, api.WithChallengeMethod(api.ChallengeMethodPost)).GetChallenge("uuid-1", "user")
+	var statusErr *api.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for a refused form, got %v", err)
+	}
+
+	// Submissions are always POSTed, whatever forms challenges may take.
+	attempt, err := service.NewTorusChallengeSolver(api.NewClient(server.URL)).SolveChallenge("user")
+	if err != nil || !attempt.Submission.Accepted() {
+		t.Errorf("Expected an accepted submission after negotiating query, got %+v, %v", attempt, err)
+	}
+}
+
+func TestSolveChallengeEndToEnd(t *testing.T) {
+	mock := NewServer(Config{Seed: 7, MaxWidth: 30, MaxHeight: 30})
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	solver := service.NewTorusChallengeSolver(api.NewClient(server.URL))
+	attempt, err := solver.SolveChallenge("offline-user")
+	if err != nil {
+		t.Fatalf("SolveChallenge failed: %v", err)
+	}
+	if !attempt.Submission.Accepted() {
+		t.Errorf("Expected accepted submission, got %+v", attempt.Submission)
+	}
+
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if len(mock.grades) != 1 {
+		t.Fatalf("Expected exactly one graded submission, got %d", len(mock.grades))
+	}
+	for uuid, grade := range mock.grades {
+		if !grade.Accepted {
+			t.Errorf("Submission for %s was rejected: %+v", uuid, grade)
+		}
+		if mock.users[uuid] != "offline-user" {
+			t.Errorf("Expected user offline-user, got %q", mock.users[uuid])
+		}
+	}
+}
+
+func TestSolveChallengePhaseTimeout(t *testing.T) {
+	mock := NewServer(Config{Seed: 7})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/challenge-me-easy" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		mock.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	solver, err := service.NewTorusChallengeSolverWithConfig(api.NewClient(server.URL), service.SolverConfig{
+		Timeouts: service.PhaseTimeouts{Challenge: 20 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create solver: %v", err)
+	}
+
+	_, err = solver.SolveChallengeContext(context.Background(), "user")
+
+	var phaseErr *service.PhaseError
+	if !errors.As(err, &phaseErr) {
+		t.Fatalf("Expected PhaseError, got %v", err)
+	}
+	if phaseErr.Phase != service.PhaseChallenge || !phaseErr.Cancelled() {
+		t.Errorf("Expected cancelled challenge phase, got %v", phaseErr)
+	}
+}
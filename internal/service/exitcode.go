@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"torus-neighbors/internal/api"
+)
+
+// Process exit codes reported by the CLI. 2 is left to the flag package for
+// usage errors.
+const (
+	ExitOK          = 0
+	ExitFailure     = 1
+	ExitRejected    = 3 // the API answered with a 4xx status
+	ExitServerError = 4 // the API answered with a 5xx or other unexpected status
+	ExitTransport   = 5 // no response, e.g. connection refused or timeout
+	ExitDecode      = 6 // the API response could not be decoded or failed validation
+	ExitCompute     = 7 // the challenge could not be solved locally
+	ExitWrongAnswer = 8 // the submission was received but judged incorrect
+	ExitCircuitOpen = 9 // the request was refused locally by an open circuit breaker
+	ExitCancelled   = 130
+)
+
+// ExitCode maps an error returned by SolveChallenge to a process exit code.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var phaseErr *PhaseError
+	if errors.As(err, &phaseErr) && phaseErr.Cancelled() {
+		return ExitCancelled
+	}
+
+	var statusErr *api.StatusError
+	var circuitErr *api.CircuitOpenError
+	var transportErr *api.TransportError
+	var decodeErr *api.DecodeError
+	var validationErr *api.ValidationError
+	switch {
+	case errors.As(err, &statusErr):
+		if statusErr.ClientError() {
+			return ExitRejected
+		}
+		return ExitServerError
+	case errors.As(err, &circuitErr):
+		return ExitCircuitOpen
+	case errors.As(err, &transportErr):
+		return ExitTransport
+	case errors.As(err, &decodeErr), errors.As(err, &validationErr):
+		return ExitDecode
+	case phaseErr != nil && phaseErr.Phase == PhaseCompute:
+		return ExitCompute
+	}
+	return ExitFailure
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"torus-neighbors/internal/api"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"success", nil, ExitOK},
+		{"rejected submission", &PhaseError{Phase: PhaseSubmit, Err: fmt.Errorf("failed to submit solution: %w",
+			&api.StatusError{Endpoint: "POST /challenge-me-easy", StatusCode: 400, Body: "bad hash"})}, ExitRejected},
+		{"server error", &PhaseError{Phase: PhasePing, Err: &api.StatusError{Endpoint: "GET /ping", StatusCode: 503}}, ExitServerError},
+		{"transport error", &PhaseError{Phase: PhasePing, Err: &api.TransportError{Endpoint: "GET /ping", Err: errors.New("connection refused")}}, ExitTransport},
+		{"invalid response", &PhaseError{Phase: PhaseChallenge, Err: &api.ValidationError{Endpoint: "GET /challenge-me-easy", Problems: []string{"missing field set_z"}}}, ExitDecode},
+		{"circuit open", &PhaseError{Phase: PhasePing, Err: &api.CircuitOpenError{Endpoint: "GET /ping", State: api.BreakerOpen}}, ExitCircuitOpen},
+		{"decode error", &PhaseError{Phase: PhaseChallenge, Err: &api.DecodeError{Endpoint: "GET /challenge-me-easy", Err: errors.New("unexpected EOF")}}, ExitDecode},
+		{"cancelled", &PhaseError{Phase: PhaseChallenge, Err: &api.TransportError{Endpoint: "GET /challenge-me-easy", Err: context.Canceled}}, ExitCancelled},
+		{"invalid challenge", &PhaseError{Phase: PhaseCompute, Err: errors.New("invalid width value 'x'")}, ExitCompute},
+		{"other", errors.New("boom"), ExitFailure},
+	}
+
+	for _, tt := range tests {
+		if actual := ExitCode(tt.err); actual != tt.expected {
+			t.Errorf("%s: expected exit code %d, got %d", tt.name, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"torus-neighbors/internal/api"
+)
+
+// ChallengeKind describes one challenge variant: the endpoint that issues and
+// grades it, the shape of its request and response, and how to solve it. Use
+// NewChallengeKind to build one from typed functions.
+type ChallengeKind struct {
+	Name        string
+	Description string
+	// Path is the API endpoint. Challenges are requested from it in the form
+	// chosen by api.WithChallengeMethod; answers are POSTed to it.
+	Path string
+	// Fetch requests a challenge. When nil, NewRequest and NewResponse are
+	// sent through a GenericChallengeAPI instead.
+	Fetch func(ctx context.Context, client ChallengeAPI, uuid, user string) (any, error)
+	// NewRequest builds the body asking for a challenge.
+	NewRequest func(uuid, user string) any
+	// NewResponse returns the pointer the challenge is decoded into.
+	NewResponse func() any
+	// Describe renders a decoded challenge for the log.
+	Describe func(challenge any) string
+	// Solve computes the answer for a decoded challenge.
+	// ctx is cancelled when the caller of SolveChallengeContext gives up.
+	Solve func(ctx context.Context, s *TorusChallengeSolver, challenge any) (*KindSolution, error)
+	// Submit grades a solution. When nil, its Answer is posted to Path
+	// through a GenericChallengeAPI.
+	Submit func(ctx context.Context, client ChallengeAPI, solution *KindSolution) (*api.SubmissionResult, error)
+}
+
+// KindSolution is a solved challenge, ready for submission.
+type KindSolution struct {
+	// UUID identifies the challenge, as echoed by the server.
+	UUID   string
+	Result *ChallengeResult
+	// Answer is the body submitted to the kind's endpoint.
+	Answer any
+}
+
+// NewChallengeKind declares a kind whose request and response bodies are the
+// JSON encodings of Req and Resp.
+func NewChallengeKind[Req, Resp any](name, description, path string,
+	newRequest func(uuid, user string) Req,
+	solve func(ctx context.Context, s *TorusChallengeSolver, challenge *Resp) (*KindSolution, error),
+) *ChallengeKind {
+	return &ChallengeKind{
+		Name:        name,
+		Description: description,
+		Path:        path,
+		NewRequest:  func(uuid, user string) any { return newRequest(uuid, user) },
+		NewResponse: func() any { return new(Resp) },
+		Describe:    func(challenge any) string { return fmt.Sprintf("%+v", *challenge.(*Resp)) },
+		Solve: func(ctx context.Context, s *TorusChallengeSolver, challenge any) (*KindSolution, error) {
+			return solve(ctx, s, challenge.(*Resp))
+		},
+	}
+}
+
+func (k *ChallengeKind) validate() error {
+	if k.Name == "" || k.Path == "" {
+		return errors.New("challenge kind needs a name and a path")
+	}
+	if k.Solve == nil || (k.Fetch == nil && (k.NewRequest == nil || k.NewResponse == nil)) {
+		return fmt.Errorf("challenge kind %s is missing its request, response or solver", k.Name)
+	}
+	return nil
+}
+
+// generic reports whether the kind talks to the API through
+// GenericChallengeAPI.
+func (k *ChallengeKind) generic() bool {
+	return k.Fetch == nil || k.Submit == nil
+}
+
+func (k *ChallengeKind) fetch(ctx context.Context, client ChallengeAPI, uuid, user string) (any, error) {
+	if k.Fetch != nil {
+		return k.Fetch(ctx, client, uuid, user)
+	}
+	challenge := k.NewResponse()
+	if err := client.(GenericChallengeAPI).FetchChallengeContext(ctx, k.Path, k.NewRequest(uuid, user), challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (k *ChallengeKind) submit(ctx context.Context, client ChallengeAPI, solution *KindSolution) (*api.SubmissionResult, error) {
+	if k.Submit != nil {
+		return k.Submit(ctx, client, solution)
+	}
+	return client.(GenericChallengeAPI).SubmitContext(ctx, k.Path, solution.UUID, solution.Answer)
+}
+
+func (k *ChallengeKind) describe(challenge any) string {
+	if k.Describe == nil {
+		return fmt.Sprintf("%+v", challenge)
+	}
+	return k.Describe(challenge)
+}
+
+// EasyChallenge is the original challenge: the 8 neighbours of a cell on a
+// plain torus, answered as a comma separated list. It uses the typed methods
+// of ChallengeAPI.
+var EasyChallenge = &ChallengeKind{
+	Name:        "easy",
+	Description: "8 neighbours on a torus",
+	Path:        api.EasyChallengePath,
+	Fetch: func(ctx context.Context, client ChallengeAPI, uuid, user string) (any, error) {
+		return client.GetChallengeContext(ctx, uuid, user)
+	},
+	Describe: func(challenge any) string {
+		c := challenge.(*api.ChallengeResponse)
+		return fmt.Sprintf("width=%s, height=%s, target_index=%s", c.SetX, c.SetY, c.SetZ)
+	},
+	Solve: func(ctx context.Context, s *TorusChallengeSolver, challenge any) (*KindSolution, error) {
+		response := challenge.(*api.ChallengeResponse)
+		result, err := s.solveChallengeResponse(ctx, response)
+		if err != nil {
+			return nil, err
+		}
+		return &KindSolution{
+			UUID:   response.UUID,
+			Result: result,
+			Answer: api.SolutionRequest{UUID: response.UUID, Result: result.NeighborsString, Hash: result.MatrixHash},
+		}, nil
+	},
+	Submit: func(ctx context.Context, client ChallengeAPI, solution *KindSolution) (*api.SubmissionResult, error) {
+		return client.SubmitSolutionContext(ctx, solution.UUID, solution.Result.NeighborsString, solution.Result.MatrixHash)
+	},
+}
+
+var (
+	challengeKindsMu sync.RWMutex
+	challengeKinds   = []*ChallengeKind{EasyChallenge}
+)
+
+// RegisterChallengeKind makes kind available to ParseChallengeKind, usually
+// from an init function. Kinds built with NewChallengeKind need an API client
+// that implements GenericChallengeAPI.
+func RegisterChallengeKind(kind *ChallengeKind) error {
+	if err := kind.validate(); err != nil {
+		return err
+	}
+
+	challengeKindsMu.Lock()
+	defer challengeKindsMu.Unlock()
+	if findChallengeKind(kind.Name) != nil {
+		return fmt.Errorf("challenge kind %s is already registered", kind.Name)
+	}
+	challengeKinds = append(challengeKinds, kind)
+	return nil
+}
+
+func ParseChallengeKind(name string) (*ChallengeKind, error) {
+	challengeKindsMu.RLock()
+	kind := findChallengeKind(name)
+	challengeKindsMu.RUnlock()
+	if kind == nil {
+		return nil, fmt.Errorf("unknown challenge kind %q, expected one of %s", name, ChallengeKindNames())
+	}
+	return kind, nil
+}
+
+func ChallengeKindNames() string {
+	challengeKindsMu.RLock()
+	defer challengeKindsMu.RUnlock()
+	names := make([]string, len(challengeKinds))
+	for i, kind := range challengeKinds {
+		names[i] = kind.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// findChallengeKind expects challengeKindsMu to be held.
+func findChallengeKind(name string) *ChallengeKind {
+	for _, kind := range challengeKinds {
+		if strings.EqualFold(kind.Name, name) {
+			return kind
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"torus-neighbors/internal/api"
+	"torus-neighbors/internal/domain"
+)
+
+// hardChallengeResponse adds a neighbourhood radius and a surface topology.
+type hardChallengeResponse struct {
+	UUID        string `json:"uuid"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	TargetIndex int    `json:"target_index"`
+	// Radius of the Moore neighbourhood; 0 means 1.
+	Radius int `json:"radius"`
+	// Topology is a domain.ParseTopology name; empty means torus.
+	Topology string `json:"topology"`
+}
+
+// hardSolutionRequest answers a hard challenge with a JSON array of indexes.
+type hardSolutionRequest struct {
+	UUID      string `json:"uuid"`
+	Neighbors []int  `json:"neighbors"`
+	Hash      string `json:"hash"`
+}
+
+// hardChallenge is an example kind built with NewChallengeKind against an
+// endpoint the real API does not have; it is not registered.
+var hardChallenge = NewChallengeKind("hard", "Moore neighbourhood of any radius on any topology", "/challenge-me-hard",
+	func(uuid, user string) api.ChallengeRequest {
+		return api.ChallengeRequest{UUID: uuid, User: user}
+	},
+	func(ctx context.Context, s *TorusChallengeSolver, challenge *hardChallengeResponse) (*KindSolution, error) {
+		radius := challenge.Radius
+		if radius == 0 {
+			radius = 1
+		}
+		stencil, err := domain.MooreStencil(radius)
+		if err != nil {
+			return nil, fmt.Errorf("invalid radius: %w", err)
+		}
+
+		var topology domain.Topology
+		if challenge.Topology != "" {
+			if topology, err = domain.ParseTopology(challenge.Topology); err != nil {
+				return nil, err
+			}
+		}
+
+		result, neighbors, err := s.ComputeSolutionWithConfig(ctx, challenge.Width, challenge.Height, challenge.TargetIndex,
+			domain.MatrixConfig{Topology: topology}, domain.NeighborFinderConfig{Stencil: stencil})
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute solution: %w", err)
+		}
+		return &KindSolution{
+			UUID:   challenge.UUID,
+			Result: result,
+			Answer: hardSolutionRequest{UUID: challenge.UUID, Neighbors: neighbors, Hash: result.MatrixHash},
+		}, nil
+	},
+)
+
+func solveKind(t *testing.T, kind *ChallengeKind, fake *fakeChallengeAPI) (*Attempt, error) {
+	t.Helper()
+	solver, err := NewTorusChallengeSolverWithConfig(fake, SolverConfig{Kind: kind})
+	if err != nil {
+		t.Fatalf("Failed to create solver: %v", err)
+	}
+	return solver.SolveChallenge("user")
+}
+
+func TestHardChallenge(t *testing.T) {
+	fake := &fakeChallengeAPI{challenge: map[string]any{"width": 4, "height": 4, "target_index": 5}}
+
+	attempt, err := solveKind(t, hardChallenge, fake)
+	if err != nil {
+		t.Fatalf("SolveChallenge failed: %v", err)
+	}
+
+	if fake.requestedPath != "/challenge-me-hard" || fake.submittedPath != "/challenge-me-hard" {
+		t.Errorf("Expected hard endpoint, got %s and %s", fake.requestedPath, fake.submittedPath)
+	}
+	// With the defaults (radius 1, torus) the answer matches the easy kind.
+	neighbors, ok := fake.submitted["neighbors"].([]any)
+	if !ok || len(neighbors) != 8 || neighbors[0] != float64(0) || neighbors[7] != float64(10) {
+		t.Errorf("Expected neighbors as a JSON array, got %v", fake.submitted)
+	}
+	if fake.submitted["hash"] != "hJVz5fi5z2YecMNLsihGJQHBpAGUAYitNUOFGmjBg38=" || fake.submitted["uuid"] != fake.requestedUUID {
+		t.Errorf("Unexpected submission %v", fake.submitted)
+	}
+	if challenge, ok := attempt.Challenge.(*hardChallengeResponse); !ok || challenge.Width != 4 {
+		t.Errorf("Expected decoded hard challenge, got %#v", attempt.Challenge)
+	}
+}
+
+func TestHardChallengeRadiusAndTopology(t *testing.T) {
+	fake := &fakeChallengeAPI{challenge: map[string]any{
+		"width": 5, "height": 5, "target_index": 0, "radius": 2, "topology": "bounded",
+	}}
+
+	if _, err := solveKind(t, hardChallenge, fake); err != nil {
+		t.Fatalf("SolveChallenge failed: %v", err)
+	}
+
+	var submitted hardSolutionRequest
+	roundTrip(fake.submitted, &submitted)
+	expected := []int{1, 2, 5, 6, 7, 10, 11, 12}
+	if !slices.Equal(submitted.Neighbors, expected) {
+		t.Errorf("Expected %v for a corner with radius 2 on a bounded grid, got %v", expected, submitted.Neighbors)
+	}
+
+	fake.challenge["topology"] = "sphere"
+	_, err := solveKind(t, hardChallenge, fake)
+	var phaseErr *PhaseError
+	if !errors.As(err, &phaseErr) || phaseErr.Phase != PhaseCompute {
+		t.Errorf("Expected compute failure for unknown topology, got %v", err)
+	}
+}
+
+func TestChallengeKindRegistry(t *testing.T) {
+	kind, err := ParseChallengeKind("EASY")
+	if err != nil || kind != EasyChallenge {
+		t.Errorf("Expected easy kind, got %v, %v", kind, err)
+	}
+	if _, err := ParseChallengeKind("medium"); err == nil {
+		t.Error("Unknown kind should be rejected")
+	}
+
+	if err := RegisterChallengeKind(&ChallengeKind{Name: "easy"}); err == nil {
+		t.Error("Incomplete kind should be rejected")
+	}
+	duplicate := *EasyChallenge
+	if err := RegisterChallengeKind(&duplicate); err == nil {
+		t.Error("Duplicate kind should be rejected")
+	}
+
+	type echoRequest struct {
+		ID string `json:"id"`
+	}
+	type echoChallenge struct {
+		ID    string `json:"uuid"`
+		Index int    `json:"index"`
+	}
+	echo := NewChallengeKind("echo", "returns the index", "/echo",
+		func(uuid, user string) echoRequest { return echoRequest{ID: uuid} },
+		func(ctx context.Context, s *TorusChallengeSolver, challenge *echoChallenge) (*KindSolution, error) {
+			return &KindSolution{
+				UUID:   challenge.ID,
+				Result: &ChallengeResult{},
+				Answer: map[string]int{"index": challenge.Index},
+			}, nil
+		},
+	)
+	if err := RegisterChallengeKind(echo); err != nil {
+		t.Fatalf("RegisterChallengeKind failed: %v", err)
+	}
+	t.Cleanup(func() {
+		challengeKindsMu.Lock()
+		challengeKinds = challengeKinds[:len(challengeKinds)-1]
+		challengeKindsMu.Unlock()
+	})
+
+	if kind, err := ParseChallengeKind("echo"); err != nil || kind != echo {
+		t.Fatalf("Registered kind not found: %v", err)
+	}
+
+	fake := &fakeChallengeAPI{challenge: map[string]any{"index": 7}}
+	attempt, err := solveKind(t, echo, fake)
+	if err != nil {
+		t.Fatalf("SolveChallenge failed: %v", err)
+	}
+	if fake.submittedPath != "/echo" || fake.submitted["index"] != float64(7) || !attempt.Submission.Accepted() {
+		t.Errorf("Unexpected submission %v to %s", fake.submitted, fake.submittedPath)
+	}
+}
+
+// typedChallengeAPI hides the generic methods of the API it wraps.
+type typedChallengeAPI struct {
+	ChallengeAPI
+}
+
+func TestChallengeKindNeedsGenericAPI(t *testing.T) {
+	fake := newFakeChallengeAPI()
+	typed := typedChallengeAPI{fake}
+
+	solver, err := NewTorusChallengeSolverWithConfig(typed, SolverConfig{Kind: EasyChallenge})
+	if err != nil {
+		t.Fatalf("Easy kind should work with the typed API: %v", err)
+	}
+	if _, err := solver.SolveChallenge("user"); err != nil || fake.submitted["result"] != "0,1,2,4,6,8,9,10" {
+		t.Errorf("Expected easy submission through the typed API, got %v, %v", fake.submitted, err)
+	}
+
+	if _, err := NewTorusChallengeSolverWithConfig(typed, SolverConfig{Kind: hardChallenge}); err == nil {
+		t.Error("A generic kind should be rejected without a GenericChallengeAPI")
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Phase string
+
+const (
+	PhasePing      Phase = "ping"
+	PhaseChallenge Phase = "challenge"
+	PhaseCompute   Phase = "compute"
+	PhaseSubmit    Phase = "submit"
+)
+
+// PhaseTimeouts bounds each API phase of SolveChallengeContext. Zero means the
+// phase is only limited by the parent context and the HTTP client timeout.
+type PhaseTimeouts struct {
+	Ping      time.Duration
+	Challenge time.Duration
+	Submit    time.Duration
+}
+
+// PhaseError records which phase of the challenge flow failed.
+type PhaseError struct {
+	Phase Phase
+	Err   error
+}
+
+func (e *PhaseError) Error() string {
+	if e.Cancelled() {
+		return fmt.Sprintf("%s phase cancelled: %v", e.Phase, e.Err)
+	}
+	return fmt.Sprintf("%s phase failed: %v", e.Phase, e.Err)
+}
+
+func (e *PhaseError) Unwrap() error {
+	return e.Err
+}
+
+// Cancelled reports whether the phase stopped because its context was
+// cancelled or its deadline expired.
+func (e *PhaseError) Cancelled() bool {
+	return errors.Is(e.Err, context.Canceled) || errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+func phaseContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -15,83 +16,235 @@ type ChallengeResult struct {
 	MatrixHash      string
 }
 
+// Attempt describes one run of the challenge flow.
+type Attempt struct {
+	UUID string
+	Kind string
+	// Challenge is the decoded response, e.g. *api.ChallengeResponse for
+	// EasyChallenge.
+	Challenge  any
+	Solution   *ChallengeResult
+	Submission *api.SubmissionResult
+}
+
+type SolverConfig struct {
+	// Hash selects the digest used for the matrix hash; the zero value is the
+	// challenge default of SHA256 encoded as standard base64.
+	Hash domain.HasherConfig
+	// HashWorkers > 0 hashes the matrix on that many goroutines with
+	// MatrixHasher.CalculateHashParallel, which stops when the solve context
+	// is cancelled; 0 hashes it sequentially.
+	HashWorkers int
+	// Timeouts bounds the individual API phases.
+	Timeouts PhaseTimeouts
+	// Kind selects the challenge variant; nil means EasyChallenge.
+	Kind *ChallengeKind
+}
+
+// ChallengeAPI is the remote side of the challenge; *api.Client implements it
+// over HTTP.
+type ChallengeAPI interface {
+	PingContext(ctx context.Context) error
+	GetChallengeContext(ctx context.Context, uuid, user string) (*api.ChallengeResponse, error)
+	SubmitSolutionContext(ctx context.Context, uuid, result, hash string) (*api.SubmissionResult, error)
+}
+
+var _ ChallengeAPI = (*api.Client)(nil)
+
+// GenericChallengeAPI is implemented by ChallengeAPIs that can serve any
+// challenge kind, such as *api.Client. Requests and answers are JSON encoded,
+// responses are decoded into the pointer passed as response.
+type GenericChallengeAPI interface {
+	FetchChallengeContext(ctx context.Context, path string, request, response any) error
+	SubmitContext(ctx context.Context, path, uuid string, answer any) (*api.SubmissionResult, error)
+}
+
+var _ GenericChallengeAPI = (*api.Client)(nil)
+
+// breakerReporter is implemented by ChallengeAPIs that guard their endpoints
+// with circuit breakers, such as *api.Client.
+type breakerReporter interface {
+	CircuitBreakerStates() map[string]api.BreakerState
+}
+
+var _ breakerReporter = (*api.Client)(nil)
+
 type TorusChallengeSolver struct {
-	apiClient *api.Client
+	apiClient ChallengeAPI
+	config    SolverConfig
 }
 
-func NewTorusChallengeSolver(apiClient *api.Client) *TorusChallengeSolver {
+func NewTorusChallengeSolver(apiClient ChallengeAPI) *TorusChallengeSolver {
 	return &TorusChallengeSolver{
 		apiClient: apiClient,
 	}
 }
 
-func (s *TorusChallengeSolver) SolveChallenge(userIdentifier string) error {
+func NewTorusChallengeSolverWithConfig(apiClient ChallengeAPI, config SolverConfig) (*TorusChallengeSolver, error) {
+	if err := config.Hash.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid solver config: %w", err)
+	}
+	if config.HashWorkers < 0 {
+		return nil, fmt.Errorf("invalid solver config: hash workers must not be negative, got %d", config.HashWorkers)
+	}
+	if config.Kind != nil {
+		if err := config.Kind.validate(); err != nil {
+			return nil, fmt.Errorf("invalid solver config: %w", err)
+		}
+		if _, ok := apiClient.(GenericChallengeAPI); config.Kind.generic() && !ok {
+			return nil, fmt.Errorf("invalid solver config: challenge kind %s needs a GenericChallengeAPI", config.Kind.Name)
+		}
+	}
+
+	return &TorusChallengeSolver{
+		apiClient: apiClient,
+		config:    config,
+	}, nil
+}
+
+func (s *TorusChallengeSolver) SolveChallenge(userIdentifier string) (*Attempt, error) {
+	return s.SolveChallengeContext(context.Background(), userIdentifier)
+}
+
+// SolveChallengeContext runs the full challenge flow. Cancelling ctx aborts
+// any in-flight request; failures are reported as *PhaseError. A rejected
+// answer is not an error: check the returned Attempt's Submission verdict.
+func (s *TorusChallengeSolver) SolveChallengeContext(ctx context.Context, userIdentifier string) (*Attempt, error) {
 	challengeUUID := uuid.New().String()
 	fmt.Printf("Generated UUID for challenge: %s\n", challengeUUID)
 
 	fmt.Println("Testing API connection...")
-	if err := s.apiClient.Ping(); err != nil {
-		return fmt.Errorf("failed to ping API: %w", err)
+	pingCtx, cancel := phaseContext(ctx, s.config.Timeouts.Ping)
+	err := s.apiClient.PingContext(pingCtx)
+	cancel()
+	if err != nil {
+		return nil, &PhaseError{Phase: PhasePing, Err: fmt.Errorf("failed to ping API: %w", err)}
 	}
 	fmt.Println("API connection successful!")
 
-	fmt.Println("Requesting challenge from API...")
-	challenge, err := s.apiClient.GetChallenge(challengeUUID, userIdentifier)
+	kind := s.kind()
+	fmt.Printf("Requesting %s challenge from API...\n", kind.Name)
+	challengeCtx, cancel := phaseContext(ctx, s.config.Timeouts.Challenge)
+	challenge, err := kind.fetch(challengeCtx, s.apiClient, challengeUUID, userIdentifier)
+	cancel()
 	if err != nil {
-		return fmt.Errorf("failed to get challenge: %w", err)
+		return nil, &PhaseError{Phase: PhaseChallenge, Err: fmt.Errorf("failed to get challenge: %w", err)}
 	}
 
-	fmt.Printf("Received challenge: width=%s, height=%s, target_index=%s\n",
-		challenge.SetX, challenge.SetY, challenge.SetZ)
+	fmt.Printf("Received challenge: %s\n", kind.describe(challenge))
+
+	solution, err := kind.Solve(ctx, s, challenge)
+	if err != nil {
+		return nil, &PhaseError{Phase: PhaseCompute, Err: err}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, &PhaseError{Phase: PhaseCompute, Err: err}
+	}
+	result := solution.Result
+
+	fmt.Printf("Solution computed:\n")
+	fmt.Printf("  Neighbors: %s\n", result.NeighborsString)
+	fmt.Printf("  Matrix Hash: %s\n", result.MatrixHash)
 
+	fmt.Println("Submitting solution to API...", solution.UUID, result.NeighborsString, result.MatrixHash)
+	submitCtx, cancel := phaseContext(ctx, s.config.Timeouts.Submit)
+	submission, err := kind.submit(submitCtx, s.apiClient, solution)
+	cancel()
+	if err != nil {
+		return nil, &PhaseError{Phase: PhaseSubmit, Err: fmt.Errorf("failed to submit solution: %w", err)}
+	}
+
+	fmt.Printf("Submission result: %s\n", submission)
+	if submission.Verdict == api.VerdictRejected {
+		fmt.Println("Challenge completed, but the answer was rejected.")
+	} else {
+		fmt.Println("Challenge completed successfully!")
+	}
+
+	return &Attempt{
+		UUID:       challengeUUID,
+		Kind:       kind.Name,
+		Challenge:  challenge,
+		Solution:   result,
+		Submission: submission,
+	}, nil
+}
+
+// CircuitBreakerStates reports the circuit breaker state of every endpoint
+// the API client has used, keyed like "GET /ping". It is nil when the client
+// has no breakers.
+func (s *TorusChallengeSolver) CircuitBreakerStates() map[string]api.BreakerState {
+	if reporter, ok := s.apiClient.(breakerReporter); ok {
+		return reporter.CircuitBreakerStates()
+	}
+	return nil
+}
+
+func (s *TorusChallengeSolver) kind() *ChallengeKind {
+	if s.config.Kind == nil {
+		return EasyChallenge
+	}
+	return s.config.Kind
+}
+
+func (s *TorusChallengeSolver) solveChallengeResponse(ctx context.Context, challenge *api.ChallengeResponse) (*ChallengeResult, error) {
 	width, err := strconv.Atoi(challenge.SetX)
 	if err != nil {
-		return fmt.Errorf("invalid width value '%s': %w", challenge.SetX, err)
+		return nil, fmt.Errorf("invalid width value '%s': %w", challenge.SetX, err)
 	}
 
 	height, err := strconv.Atoi(challenge.SetY)
 	if err != nil {
-		return fmt.Errorf("invalid height value '%s': %w", challenge.SetY, err)
+		return nil, fmt.Errorf("invalid height value '%s': %w", challenge.SetY, err)
 	}
 
 	targetIndex, err := strconv.Atoi(challenge.SetZ)
 	if err != nil {
-		return fmt.Errorf("invalid target index value '%s': %w", challenge.SetZ, err)
+		return nil, fmt.Errorf("invalid target index value '%s': %w", challenge.SetZ, err)
 	}
 
 	fmt.Printf("Computing solution for %dx%d matrix, target index %d...\n", width, height, targetIndex)
-	result, err := s.ComputeSolution(width, height, targetIndex)
+	result, err := s.ComputeSolutionContext(ctx, width, height, targetIndex)
 	if err != nil {
-		return fmt.Errorf("failed to compute solution: %w", err)
+		return nil, fmt.Errorf("failed to compute solution: %w", err)
 	}
+	return result, nil
+}
 
-	fmt.Printf("Solution computed:\n")
-	fmt.Printf("  Neighbors: %s\n", result.NeighborsString)
-	fmt.Printf("  Matrix Hash: %s\n", result.MatrixHash)
-
-	fmt.Println("Submitting solution to API...", challenge.UUID, result.NeighborsString, result.MatrixHash)
-	if err := s.apiClient.SubmitSolution(challenge.UUID, result.NeighborsString, result.MatrixHash); err != nil {
-		return fmt.Errorf("failed to submit solution: %w", err)
-	}
+func (s *TorusChallengeSolver) ComputeSolution(width, height, targetIndex int) (*ChallengeResult, error) {
+	return s.ComputeSolutionContext(context.Background(), width, height, targetIndex)
+}
 
-	fmt.Println("Challenge completed successfully!")
-	return nil
+// ComputeSolutionContext is ComputeSolution with a context; cancelling it
+// stops the hash when SolverConfig.HashWorkers hashes in parallel.
+func (s *TorusChallengeSolver) ComputeSolutionContext(ctx context.Context, width, height, targetIndex int) (*ChallengeResult, error) {
+	result, _, err := s.ComputeSolutionWithConfig(ctx, width, height, targetIndex, domain.MatrixConfig{}, domain.NeighborFinderConfig{})
+	return result, err
 }
 
-func (s *TorusChallengeSolver) ComputeSolution(width, height, targetIndex int) (*ChallengeResult, error) {
-	matrix, err := domain.NewTorusMatrix(width, height)
+// ComputeSolutionWithConfig solves on a matrix with the given topology and
+// neighbourhood, hashing it with the solver's SolverConfig. It is what kinds
+// built with NewChallengeKind call from their solve function, and it also
+// returns the neighbour indexes for kinds that submit them in another format.
+func (s *TorusChallengeSolver) ComputeSolutionWithConfig(ctx context.Context, width, height, targetIndex int,
+	matrixConfig domain.MatrixConfig, finderConfig domain.NeighborFinderConfig,
+) (*ChallengeResult, []int, error) {
+	matrix, err := domain.NewTorusMatrixWithConfig(width, height, matrixConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create torus matrix: %w", err)
+		return nil, nil, fmt.Errorf("failed to create torus matrix: %w", err)
 	}
 
 	if !matrix.IsValidIndex(targetIndex) {
-		return nil, fmt.Errorf("target index %d is invalid for %dx%d matrix", targetIndex, width, height)
+		return nil, nil, fmt.Errorf("target index %d is invalid for %dx%d matrix", targetIndex, width, height)
 	}
 
-	neighborFinder := domain.NewNeighborFinder(matrix)
+	neighborFinder, err := domain.NewNeighborFinderWithConfig(matrix, finderConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create neighbor finder: %w", err)
+	}
 	neighbors, err := neighborFinder.FindNeighbors(targetIndex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find neighbors: %w", err)
+		return nil, nil, fmt.Errorf("failed to find neighbors: %w", err)
 	}
 
 	neighborsStrings := make([]string, len(neighbors))
@@ -100,13 +253,24 @@ func (s *TorusChallengeSolver) ComputeSolution(width, height, targetIndex int) (
 	}
 	neighborsString := strings.Join(neighborsStrings, ",")
 
-	hasher := domain.NewMatrixHasher(matrix)
-	matrixHash := hasher.CalculateHash()
+	hasher, err := domain.NewMatrixHasherWithConfig(matrix, s.config.Hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create matrix hasher: %w", err)
+	}
+	var matrixHash string
+	if s.config.HashWorkers > 0 {
+		matrixHash, err = hasher.CalculateHashParallel(ctx, s.config.HashWorkers)
+	} else {
+		matrixHash, err = hasher.CalculateHash()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot hash %dx%d matrix: %w", width, height, err)
+	}
 
 	return &ChallengeResult{
 		NeighborsString: neighborsString,
 		MatrixHash:      matrixHash,
-	}, nil
+	}, neighbors, nil
 }
 
 func (s *TorusChallengeSolver) ValidateLocalExample() error {
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"torus-neighbors/internal/api"
+	"torus-neighbors/internal/domain"
+)
+
+// fakeChallengeAPI is an in-memory GenericChallengeAPI, the typed ChallengeAPI
+// methods going through the easy endpoint. It serves challenge as JSON,
+// echoing the requested uuid, and records what the solver sends it.
+type fakeChallengeAPI struct {
+	pingErr      error
+	challengeErr error
+	submitErr    error
+	challenge    map[string]any
+	submission   *api.SubmissionResult
+	// block makes FetchChallengeContext wait for its context to end.
+	block bool
+
+	requestedPath string
+	requestedUUID string
+	requestedUser string
+	submittedPath string
+	submitted     map[string]any
+}
+
+func (f *fakeChallengeAPI) PingContext(ctx context.Context) error {
+	return f.pingErr
+}
+
+func (f *fakeChallengeAPI) GetChallengeContext(ctx context.Context, uuid, user string) (*api.ChallengeResponse, error) {
+	var challenge api.ChallengeResponse
+	if err := f.FetchChallengeContext(ctx, api.EasyChallengePath, api.ChallengeRequest{UUID: uuid, User: user}, &challenge); err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (f *fakeChallengeAPI) SubmitSolutionContext(ctx context.Context, uuid, result, hash string) (*api.SubmissionResult, error) {
+	return f.SubmitContext(ctx, api.EasyChallengePath, uuid, api.SolutionRequest{UUID: uuid, Result: result, Hash: hash})
+}
+
+func (f *fakeChallengeAPI) FetchChallengeContext(ctx context.Context, path string, request, response any) error {
+	var fields struct {
+		UUID string `json:"uuid"`
+		User string `json:"user"`
+	}
+	roundTrip(request, &fields)
+	f.requestedPath, f.requestedUUID, f.requestedUser = path, fields.UUID, fields.User
+
+	if f.block {
+		<-ctx.Done()
+		return &api.TransportError{Endpoint: "GET " + path, Err: ctx.Err()}
+	}
+	if f.challengeErr != nil {
+		return f.challengeErr
+	}
+
+	challenge := maps.Clone(f.challenge)
+	challenge["uuid"] = fields.UUID
+	return roundTrip(challenge, response)
+}
+
+func (f *fakeChallengeAPI) SubmitContext(ctx context.Context, path, uuid string, answer any) (*api.SubmissionResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.submittedPath = path
+	roundTrip(answer, &f.submitted)
+	if f.submitErr != nil {
+		return nil, f.submitErr
+	}
+	if f.submission != nil {
+		return f.submission, nil
+	}
+	return &api.SubmissionResult{Verdict: api.VerdictAccepted}, nil
+}
+
+// roundTrip mimics the wire: value is encoded to JSON and decoded into target.
+func roundTrip(value, target any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+func newFakeChallengeAPI() *fakeChallengeAPI {
+	return &fakeChallengeAPI{
+		challenge: map[string]any{"set_x": "4", "set_y": "4", "set_z": "5"},
+	}
+}
+
+func TestSolveChallenge(t *testing.T) {
+	fake := newFakeChallengeAPI()
+	solver := NewTorusChallengeSolver(fake)
+
+	attempt, err := solver.SolveChallenge("test-user")
+	if err != nil {
+		t.Fatalf("SolveChallenge failed: %v", err)
+	}
+
+	if fake.requestedUser != "test-user" {
+		t.Errorf("Expected user test-user, got %q", fake.requestedUser)
+	}
+	if fake.requestedUUID == "" || attempt.UUID != fake.requestedUUID {
+		t.Errorf("Attempt UUID %q does not match requested %q", attempt.UUID, fake.requestedUUID)
+	}
+
+	expected := map[string]any{
+		"uuid":   fake.requestedUUID,
+		"result": "0,1,2,4,6,8,9,10",
+		"hash":   "hJVz5fi5z2YecMNLsihGJQHBpAGUAYitNUOFGmjBg38=",
+	}
+	if !maps.Equal(fake.submitted, expected) {
+		t.Errorf("Expected submission %v, got %v", expected, fake.submitted)
+	}
+	if fake.requestedPath != api.EasyChallengePath || fake.submittedPath != api.EasyChallengePath {
+		t.Errorf("Expected easy endpoint, got %s and %s", fake.requestedPath, fake.submittedPath)
+	}
+	if !attempt.Submission.Accepted() || attempt.Kind != "easy" {
+		t.Errorf("Expected accepted easy submission, got %+v", attempt)
+	}
+	if attempt.Solution.NeighborsString != expected["result"] {
+		t.Errorf("Expected solution %s, got %s", expected["result"], attempt.Solution.NeighborsString)
+	}
+}
+
+func TestSolveChallengeRejectedAnswer(t *testing.T) {
+	fake := newFakeChallengeAPI()
+	fake.submission = &api.SubmissionResult{Verdict: api.VerdictRejected, Message: "wrong hash"}
+
+	attempt, err := NewTorusChallengeSolver(fake).SolveChallenge("user")
+	if err != nil {
+		t.Fatalf("A rejected answer should not be an error, got %v", err)
+	}
+	if attempt.Submission.Verdict != api.VerdictRejected {
+		t.Errorf("Expected rejected verdict, got %s", attempt.Submission.Verdict)
+	}
+}
+
+func TestSolveChallengeErrors(t *testing.T) {
+	statusErr := &api.StatusError{Endpoint: "POST /challenge-me-easy", StatusCode: 400, Body: "bad"}
+
+	tests := []struct {
+		name      string
+		setup     func(*fakeChallengeAPI)
+		phase     Phase
+		exitCode  int
+		submitted bool
+	}{
+		{"ping fails", func(f *fakeChallengeAPI) {
+			f.pingErr = &api.TransportError{Endpoint: "GET /ping", Err: errors.New("connection refused")}
+		}, PhasePing, ExitTransport, false},
+		{"challenge request fails", func(f *fakeChallengeAPI) {
+			f.challengeErr = &api.StatusError{Endpoint: "GET /challenge-me-easy", StatusCode: 503}
+		}, PhaseChallenge, ExitServerError, false},
+		{"challenge undecodable", func(f *fakeChallengeAPI) {
+			f.challengeErr = &api.DecodeError{Endpoint: "GET /challenge-me-easy", Err: errors.New("unexpected EOF")}
+		}, PhaseChallenge, ExitDecode, false},
+		{"invalid width", func(f *fakeChallengeAPI) {
+			f.challenge["set_x"] = "four"
+		}, PhaseCompute, ExitCompute, false},
+		{"invalid height", func(f *fakeChallengeAPI) {
+			f.challenge["set_y"] = ""
+		}, PhaseCompute, ExitCompute, false},
+		{"invalid target index", func(f *fakeChallengeAPI) {
+			f.challenge["set_z"] = "x"
+		}, PhaseCompute, ExitCompute, false},
+		{"target index out of range", func(f *fakeChallengeAPI) {
+			f.challenge["set_z"] = "16"
+		}, PhaseCompute, ExitCompute, false},
+		{"non-positive dimensions", func(f *fakeChallengeAPI) {
+			f.challenge["set_x"] = "0"
+		}, PhaseCompute, ExitCompute, false},
+		{"submission fails", func(f *fakeChallengeAPI) {
+			f.submitErr = statusErr
+		}, PhaseSubmit, ExitRejected, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeChallengeAPI()
+			tt.setup(fake)
+
+			attempt, err := NewTorusChallengeSolver(fake).SolveChallenge("user")
+			if attempt != nil {
+				t.Errorf("Expected no attempt on failure, got %+v", attempt)
+			}
+
+			var phaseErr *PhaseError
+			if !errors.As(err, &phaseErr) {
+				t.Fatalf("Expected PhaseError, got %v", err)
+			}
+			if phaseErr.Phase != tt.phase {
+				t.Errorf("Expected %s phase, got %s", tt.phase, phaseErr.Phase)
+			}
+			if phaseErr.Cancelled() {
+				t.Error("Failure should not be reported as cancellation")
+			}
+			if code := ExitCode(err); code != tt.exitCode {
+				t.Errorf("Expected exit code %d, got %d", tt.exitCode, code)
+			}
+			if (fake.submitted != nil) != tt.submitted {
+				t.Errorf("Expected submitted=%t, got %+v", tt.submitted, fake.submitted)
+			}
+		})
+	}
+}
+
+func TestSolveChallengeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fake := newFakeChallengeAPI()
+	fake.pingErr = &api.TransportError{Endpoint: "GET /ping", Err: context.Canceled}
+
+	_, err := NewTorusChallengeSolver(fake).SolveChallengeContext(ctx, "user")
+	if ExitCode(err) != ExitCancelled {
+		t.Errorf("Expected cancelled exit code, got %d for %v", ExitCode(err), err)
+	}
+}
+
+func TestSolveChallengeCancelledWhileHashing(t *testing.T) {
+	fake := newFakeChallengeAPI()
+	fake.challenge = map[string]any{"set_x": "4000", "set_y": "4000", "set_z": "5"}
+
+	solver, err := NewTorusChallengeSolverWithConfig(fake, SolverConfig{HashWorkers: 4})
+	if err != nil {
+		t.Fatalf("Failed to create solver: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = solver.SolveChallengeContext(ctx, "user")
+
+	var phaseErr *PhaseError
+	if !errors.As(err, &phaseErr) || phaseErr.Phase != PhaseCompute || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the compute phase to hit the deadline, got %v", err)
+	}
+	if ExitCode(err) != ExitCancelled {
+		t.Errorf("Expected cancelled exit code, got %d", ExitCode(err))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Hashing should stop at the deadline, took %v", elapsed)
+	}
+	if fake.submitted != nil {
+		t.Errorf("Nothing should be submitted, got %v", fake.submitted)
+	}
+}
+
+func TestSolveChallengePhaseTimeout(t *testing.T) {
+	fake := newFakeChallengeAPI()
+	fake.block = true
+
+	solver, err := NewTorusChallengeSolverWithConfig(fake, SolverConfig{
+		Timeouts: PhaseTimeouts{Challenge: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create solver: %v", err)
+	}
+
+	_, err = solver.SolveChallengeContext(context.Background(), "user")
+
+	var phaseErr *PhaseError
+	if !errors.As(err, &phaseErr) || phaseErr.Phase != PhaseChallenge || !phaseErr.Cancelled() {
+		t.Fatalf("Expected cancelled challenge phase, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestNewTorusChallengeSolverWithConfigRejectsInvalidHash(t *testing.T) {
+	_, err := NewTorusChallengeSolverWithConfig(newFakeChallengeAPI(), SolverConfig{})
+	if err != nil {
+		t.Fatalf("Zero config should be valid, got %v", err)
+	}
+
+	_, err = NewTorusChallengeSolverWithConfig(newFakeChallengeAPI(), SolverConfig{
+		Hash: domain.HasherConfig{Algorithm: "md5"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid solver config") {
+		t.Errorf("Expected invalid solver config error, got %v", err)
+	}
+}
+
+func TestComputeSolutionHashWorkers(t *testing.T) {
+	sequential, err := NewTorusChallengeSolver(newFakeChallengeAPI()).ComputeSolution(300, 200, 1234)
+	if err != nil {
+		t.Fatalf("ComputeSolution failed: %v", err)
+	}
+
+	solver, err := NewTorusChallengeSolverWithConfig(newFakeChallengeAPI(), SolverConfig{HashWorkers: 4})
+	if err != nil {
+		t.Fatalf("Failed to create solver: %v", err)
+	}
+	parallel, err := solver.ComputeSolution(300, 200, 1234)
+	if err != nil {
+		t.Fatalf("ComputeSolution with hash workers failed: %v", err)
+	}
+	if *parallel != *sequential {
+		t.Errorf("Parallel hashing should give %+v, got %+v", sequential, parallel)
+	}
+
+	if _, err := NewTorusChallengeSolverWithConfig(newFakeChallengeAPI(), SolverConfig{HashWorkers: -1}); err == nil {
+		t.Error("Negative hash workers should be rejected")
+	}
+}
+
+func TestSolverCircuitBreakerStates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	breakers := api.NewCircuitBreakers(api.BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute})
+	client := api.NewClient(server.URL, api.WithCircuitBreakers(breakers), api.WithRetryPolicy(api.NoRetry()))
+	solver := NewTorusChallengeSolver(client)
+
+	if _, err := solver.SolveChallenge("user"); ExitCode(err) != ExitServerError {
+		t.Fatalf("Expected server error on the first run, got %v", err)
+	}
+	if states := solver.CircuitBreakerStates(); states["GET /ping"] != api.BreakerOpen {
+		t.Errorf("Expected open ping breaker, got %v", states)
+	}
+	if _, err := solver.SolveChallenge("user"); ExitCode(err) != ExitCircuitOpen {
+		t.Errorf("Expected circuit open on the second run, got %v", err)
+	}
+
+	if states := NewTorusChallengeSolver(newFakeChallengeAPI()).CircuitBreakerStates(); states != nil {
+		t.Errorf("Expected no breaker states from the fake API, got %v", states)
+	}
+}